@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/gookit/color"
+)
+
+// assertCmd checks a page for scripts: it exits 0 when the text of the
+// -selector element contains -contains, or when -eval returns -expect, and 1
+// with the actual value otherwise.
+//
+//	ctfhelper assert -selector css -contains text <target>
+//	ctfhelper assert -eval expr -expect value <target>
+func assertCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("assert")
+	selector := fs.String("selector", "", "css selector of the element to check")
+	contains := fs.String("contains", "", "text the -selector element must contain")
+	expr := fs.String("eval", "", "js expression to check")
+	expect := fs.String("expect", "", "what -eval must return: a string as it is, other values as json, e.g. true or 42")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || (*selector == "") == (*expr == "") {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *selector != "" {
+		res, err := p.Eval(selectorTextJS, *selector)
+		if err != nil {
+			return err
+		}
+		if res.Value.Nil() {
+			return fmt.Errorf("assert: no element matches %q", *selector)
+		}
+		if text := res.Value.String(); !strings.Contains(text, *contains) {
+			return fmt.Errorf("assert: %q doesn't contain %q, its text is %q", *selector, *contains, text)
+		}
+	} else {
+		res, err := p.Eval(*expr)
+		if err != nil {
+			return err
+		}
+		got := res.Value.JSON("", "")
+		if res.Type == proto.RuntimeRemoteObjectTypeString {
+			got = res.Value.Str()
+		}
+		if got != *expect {
+			return fmt.Errorf("assert: %s is %s, want %s", *expr, got, *expect)
+		}
+	}
+	color.Green.Println("OK")
+	return nil
+}
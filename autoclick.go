@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+const clickablesJS = `() => {
+	` + cssPathJS + `
+	const sel = "a[href], button, input[type=submit], input[type=button], input[type=image], [onclick], [role=button]"
+	return [...document.querySelectorAll(sel)].map(el => ({
+		selector: cssPath(el),
+		label: (el.innerText || el.value || el.title || el.getAttribute("aria-label") || el.getAttribute("href") || "").trim().slice(0, 60),
+	}))
+}`
+
+// autoclickCmd clicks every clickable element of the page one by one,
+// reloading the original url in between, and reports what each click did.
+//
+//	ctfhelper autoclick [-skip regexp] [-settle 1s] <target>
+func autoclickCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("autoclick")
+	skip := fs.String("skip", `(?i)delete|remove|log ?out|sign ?out|unsubscribe|pay|purchase|reset`,
+		"`regexp` of labels or selectors that must never be clicked")
+	settle := fs.Duration("settle", time.Second, "how long to wait for effects after each click")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	skipRe, err := regexp.Compile(*skip)
+	if err != nil {
+		return err
+	}
+	normalize := regexp.MustCompile(defaultNormalize)
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	info, err := p.Info()
+	if err != nil {
+		return err
+	}
+	origin := info.URL
+
+	res, err := p.Eval(clickablesJS)
+	if err != nil {
+		return err
+	}
+	var clickables []struct {
+		Selector string `json:"selector"`
+		Label    string `json:"label"`
+	}
+	if err := unmarshalValue(res, &clickables); err != nil {
+		return err
+	}
+
+	for _, c := range clickables {
+		if skipRe.MatchString(c.Label) || skipRe.MatchString(c.Selector) {
+			fmt.Printf("skip\t%s\t%q\n", c.Selector, c.Label)
+			continue
+		}
+
+		if _, err := loadHTML(p, origin); err != nil {
+			return err
+		}
+		before, err := domHash(p, normalize)
+		if err != nil {
+			return err
+		}
+
+		effects, err := clickAndObserve(p, c.Selector, *settle)
+		if err != nil {
+			fmt.Printf("error\t%s\t%q\t%s\n", c.Selector, c.Label, err)
+			continue
+		}
+
+		if effects.url == origin {
+			if after, err := domHash(p, normalize); err == nil && after != before {
+				effects.list = append(effects.list, "dom changed")
+			}
+		}
+		if len(effects.list) == 0 {
+			effects.list = []string{"nothing"}
+		}
+		fmt.Printf("click\t%s\t%q\t%s\n", c.Selector, c.Label, strings.Join(effects.list, ", "))
+	}
+
+	_, err = loadHTML(p, origin)
+	return err
+}
+
+type clickEffects struct {
+	url  string
+	list []string
+}
+
+// clickAndObserve clicks selector and records the requests and navigation
+// that follow within settle.
+func clickAndObserve(p *rod.Page, selector string, settle time.Duration) (*clickEffects, error) {
+	el, err := p.Timeout(settle).Element(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var requests int32
+	watch, stop := p.WithCancel()
+	go watch.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		atomic.AddInt32(&requests, 1)
+	})()
+
+	info, err := p.Info()
+	if err != nil {
+		stop()
+		return nil, err
+	}
+	before := info.URL
+
+	err = el.Click(proto.InputMouseButtonLeft)
+	time.Sleep(settle)
+	stop()
+	if err != nil {
+		return nil, err
+	}
+
+	effects := &clickEffects{}
+	if n := atomic.LoadInt32(&requests); n > 0 {
+		effects.list = append(effects.list, fmt.Sprintf("%d requests", n))
+	}
+	info, err = p.Info()
+	if err != nil {
+		return nil, err
+	}
+	effects.url = info.URL
+	if info.URL != before {
+		effects.list = append(effects.list, "navigated to "+info.URL)
+	}
+	return effects, nil
+}
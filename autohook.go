@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	noAutoHook   = flag.Bool("no-auto-hook", false, "don't inject the log() hook into pages opened while the tool runs")
+	followPopups = flag.Bool("follow-popups", false, "print the target id and url of every tab or popup opened while the tool runs, and hook it even with -no-auto-hook")
+	autoDump     = flag.Bool("auto-dump", false, "with -follow-popups, also print the html of each new tab once it loaded")
+)
+
+// eachPage calls fn for every open page of b, then for every page opened
+// while the tool runs, as soon as it's created so none of its events are
+// missed. fn must not block, it delays the pages after it.
+func eachPage(b *rod.Browser, fn func(p *rod.Page)) error {
+	pages, err := b.Pages()
+	if err != nil {
+		return err
+	}
+	for _, p := range pages {
+		fn(p)
+	}
+	eachNewPage(b, func(p *rod.Page, _ *proto.TargetTargetInfo) { fn(p) })
+	return nil
+}
+
+// eachBrowserPage is eachPage for every connected browser. fn also gets the
+// b<N> name of the page's browser, empty with a single -control-url, to
+// tell the output of the browsers apart.
+func eachBrowserPage(fn func(p *rod.Page, browser string)) error {
+	for n, b := range browsers {
+		browser := ""
+		if len(browsers) > 1 {
+			browser = fmt.Sprintf("b%d", n)
+		}
+		if err := eachPage(b, func(p *rod.Page) { fn(p, browser) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withBrowser prefixes line with the b<N>/ of its browser, if any.
+func withBrowser(browser, line string) string {
+	if browser == "" {
+		return line
+	}
+	return browser + "/" + line
+}
+
+// eachNewPage calls fn for every page opened while the tool runs, with the
+// target info it was created with. fn must not block.
+func eachNewPage(b *rod.Browser, fn func(p *rod.Page, info *proto.TargetTargetInfo)) {
+	go b.EachEvent(func(e *proto.TargetTargetCreated) {
+		if e.TargetInfo.Type != proto.TargetTargetInfoTypePage {
+			return
+		}
+		p, err := b.PageFromTarget(e.TargetInfo.TargetID)
+		if err != nil {
+			logrus.WithField("target", e.TargetInfo.TargetID).WithError(err).Error("PageFromTarget")
+			return
+		}
+		fn(p, e.TargetInfo)
+	})()
+}
+
+// autoHook injects the hooks into every page created after the tool
+// connected, so log() also exists in popups and tabs opened by the challenge.
+// With -follow-popups the new pages are also announced, and dumped with
+// -auto-dump.
+func autoHook(b *rod.Browser) {
+	eachNewPage(b, func(p *rod.Page, info *proto.TargetTargetInfo) {
+		if err := injectHooks(p); err != nil {
+			logrus.WithField("target", p.TargetID).WithError(err).Error("autoHook")
+		}
+		if !*followPopups {
+			return
+		}
+
+		printLine(fmt.Sprintf("new page %s %s opener=%s", p.TargetID, info.URL, info.OpenerID))
+		if *autoDump {
+			go func() {
+				if err := p.WaitLoad(); err != nil {
+					logrus.WithField("target", p.TargetID).WithError(err).Error("auto-dump")
+					return
+				}
+				html, err := ctfhelper.HTML(p)
+				if err != nil {
+					logrus.WithField("target", p.TargetID).WithError(err).Error("auto-dump")
+					return
+				}
+				printLine(fmt.Sprintf("%s\n%s", p.TargetID, html))
+			}()
+		}
+	})
+}
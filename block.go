@@ -0,0 +1,49 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+var blockGlobs, mockFlags stringsFlag
+
+func init() {
+	flag.Var(&blockGlobs, "block", "fail the requests matching this `glob`, as if an ad blocker dropped them, repeatable")
+	flag.Var(&mockFlags, "mock", "answer the requests matching glob with the content of file, `glob=file`, repeatable")
+}
+
+// blockRules are the hijack rules of the -block and -mock flags.
+func blockRules() ([]hijackRule, error) {
+	var rules []hijackRule
+	for _, glob := range blockGlobs {
+		rules = append(rules, hijackRule{Pattern: glob, Action: "block"})
+	}
+	for _, m := range mockFlags {
+		// globs may hold a = in a query, file names hardly do
+		i := strings.LastIndex(m, "=")
+		if i <= 0 || i == len(m)-1 {
+			return nil, errors.New("-mock wants glob=file, got " + m)
+		}
+		glob, file := m[:i], m[i+1:]
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("-mock: %w", err)
+		}
+		typ := mime.TypeByExtension(filepath.Ext(file))
+		if typ == "" {
+			typ = "application/octet-stream"
+		}
+		rules = append(rules, hijackRule{
+			Pattern: glob,
+			Action:  "replace-body",
+			Body:    string(body),
+			Headers: map[string]string{"Content-Type": typ},
+		})
+	}
+	return rules, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"flag"
+	"mime"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var dumpBodiesDir = flag.String("dump-bodies", "", "write the exact bytes of every response body of every tab into `dir`")
+
+// mimeExts overrides mime.ExtensionsByType for types where its first pick is
+// not the extension people expect.
+var mimeExts = map[string]string{
+	"application/javascript": ".js",
+	"application/json":       ".json",
+	"image/jpeg":             ".jpg",
+	"image/svg+xml":          ".svg",
+	"text/html":              ".html",
+	"text/javascript":        ".js",
+	"text/plain":             ".txt",
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// dumpBodies saves every response body the open tabs of every browser, and
+// the tabs opened later, receive into dir, into its b<N> subdirectory with
+// several browsers. Identical bodies are only written once per browser.
+func dumpBodies(dir string) error {
+	lock := sync.Mutex{}
+	seen := map[string]bool{}
+
+	return eachBrowserPage(func(p *rod.Page, browser string) {
+		responses := map[proto.NetworkRequestID]*proto.NetworkResponse{}
+
+		go p.EachEvent(func(e *proto.NetworkResponseReceived) {
+			responses[e.RequestID] = e.Response
+		}, func(e *proto.NetworkLoadingFinished) {
+			res, ok := responses[e.RequestID]
+			if !ok {
+				return
+			}
+			delete(responses, e.RequestID)
+
+			body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(p)
+			if err != nil {
+				logrus.WithField("url", res.URL).WithError(err).Debug("NetworkGetResponseBody")
+				return
+			}
+			data := []byte(body.Body)
+			if body.Base64Encoded {
+				data, err = base64.StdEncoding.DecodeString(body.Body)
+				if err != nil {
+					logrus.WithField("url", res.URL).WithError(err).Error("decode body")
+					return
+				}
+			}
+
+			sum := sha256.Sum256(data)
+			hash := hex.EncodeToString(sum[:])
+			lock.Lock()
+			dup := seen[browser+hash]
+			seen[browser+hash] = true
+			lock.Unlock()
+			if dup {
+				return
+			}
+
+			name := filepath.Join(dir, browser, bodyFileName(res.URL, res.MIMEType, hash))
+			if err := utils.OutputFile(name, data); err != nil {
+				logrus.WithField("file", name).WithError(err).Error("utils.OutputFile")
+			}
+		})()
+	})
+}
+
+// bodyFileName builds a file name like "host_path_1a2b3c4d.ext" for a response.
+func bodyFileName(rawURL, mimeType, hash string) string {
+	base, ext := "body", ".bin"
+
+	u, err := url.Parse(rawURL)
+	if err == nil {
+		base = u.Host + strings.TrimSuffix(u.Path, path.Ext(u.Path))
+		ext = bodyExt(path.Ext(u.Path), mimeType)
+	}
+
+	base = strings.Trim(unsafeFileChars.ReplaceAllString(base, "_"), "_")
+	if len(base) > 100 {
+		base = base[:100]
+	}
+	return base + "_" + hash[:8] + ext
+}
+
+func bodyExt(urlExt, mimeType string) string {
+	mimeType = strings.TrimSpace(strings.Split(mimeType, ";")[0])
+
+	if urlExt != "" && strings.HasPrefix(mime.TypeByExtension(urlExt), mimeType) {
+		return urlExt
+	}
+	if ext, ok := mimeExts[mimeType]; ok {
+		return ext
+	}
+	if exts, _ := mime.ExtensionsByType(mimeType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
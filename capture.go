@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	captureFile                      = flag.String("capture", "", "append every request of every tab with its response, bodies and timing included, to this json lines `file`")
+	captureIncludes, captureExcludes stringsFlag
+)
+
+func init() {
+	flag.Var(&captureIncludes, "capture-include", "with -capture, only record the urls matching this `glob`, repeatable")
+	flag.Var(&captureExcludes, "capture-exclude", "with -capture, don't record the urls matching this `glob`, repeatable")
+}
+
+// captureRecord is one line of the -capture file, a request and what
+// answered it. Error is set for requests that failed or were blocked.
+type captureRecord struct {
+	Time            time.Time            `json:"time"`
+	Browser         string               `json:"browser,omitempty"`
+	TargetID        proto.TargetTargetID `json:"targetId"`
+	Method          string               `json:"method"`
+	URL             string               `json:"url"`
+	RequestHeaders  proto.NetworkHeaders `json:"requestHeaders"`
+	RequestBody     string               `json:"requestBody,omitempty"`
+	Status          int                  `json:"status,omitempty"`
+	ResponseHeaders proto.NetworkHeaders `json:"responseHeaders,omitempty"`
+	ResponseBody    string               `json:"responseBody,omitempty"`
+	Base64          bool                 `json:"base64,omitempty"`
+	DurationMS      float64              `json:"durationMs"`
+	Error           string               `json:"error,omitempty"`
+
+	start time.Duration
+}
+
+// captureWriter appends records to the -capture file, a line each.
+type captureWriter struct {
+	*jsonLinesFile
+	includes []*regexp.Regexp
+	excludes []*regexp.Regexp
+}
+
+// jsonLinesFile is a file the records of several tabs are appended to as
+// json lines, one whole line at a time.
+type jsonLinesFile struct {
+	lock sync.Mutex
+	name string
+	f    *os.File
+}
+
+// openJSONLines opens file for appending, closing it on shutdown.
+func openJSONLines(file string) (*jsonLinesFile, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w := &jsonLinesFile{name: file, f: f}
+	onShutdown(w.Close)
+	return w, nil
+}
+
+// startCapture records the traffic of all open tabs of every browser, and of
+// the tabs opened later, into file. It reads the traffic from the devtools network domain
+// instead of hijacking it, so the requests are the browser's own and the
+// hijack rules still apply to them.
+func startCapture(file string) error {
+	f, err := openJSONLines(file)
+	if err != nil {
+		return err
+	}
+	w := &captureWriter{jsonLinesFile: f, includes: globRegexps(captureIncludes), excludes: globRegexps(captureExcludes)}
+
+	return eachBrowserPage(w.capture)
+}
+
+// globRegexps turns hijack style globs into regexps.
+func globRegexps(globs []string) []*regexp.Regexp {
+	list := make([]*regexp.Regexp, 0, len(globs))
+	for _, g := range globs {
+		list = append(list, regexp.MustCompile(proto.PatternToReg(g)))
+	}
+	return list
+}
+
+// wants tells whether the url passes -capture-include and -capture-exclude.
+func (w *captureWriter) wants(u string) bool {
+	for _, re := range w.excludes {
+		if re.MatchString(u) {
+			return false
+		}
+	}
+	if len(w.includes) == 0 {
+		return true
+	}
+	for _, re := range w.includes {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
+
+// capture records the traffic of p in the background, until the tool exits.
+func (w *captureWriter) capture(p *rod.Page, browser string) {
+	// the callbacks of one EachEvent run one at a time, no lock needed
+	pending := map[proto.NetworkRequestID]*captureRecord{}
+	finish := func(id proto.NetworkRequestID, at *proto.MonotonicTime) *captureRecord {
+		r, ok := pending[id]
+		if !ok {
+			return nil
+		}
+		delete(pending, id)
+		if at != nil {
+			r.DurationMS = float64(at.Duration-r.start) / float64(time.Millisecond)
+		}
+		return r
+	}
+
+	go p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		// a redirect reuses the id of the request it answered
+		if r := finish(e.RequestID, e.Timestamp); r != nil && e.RedirectResponse != nil {
+			r.Status, r.ResponseHeaders = e.RedirectResponse.Status, e.RedirectResponse.Headers
+			w.Write(r)
+		}
+		if !w.wants(e.Request.URL) {
+			return
+		}
+		r := &captureRecord{
+			Time:           time.Now(),
+			Browser:        browser,
+			TargetID:       p.TargetID,
+			Method:         e.Request.Method,
+			URL:            e.Request.URL,
+			RequestHeaders: e.Request.Headers,
+			RequestBody:    e.Request.PostData,
+		}
+		if e.WallTime != nil {
+			r.Time = e.WallTime.Time
+		}
+		if e.Timestamp != nil {
+			r.start = e.Timestamp.Duration
+		}
+		pending[e.RequestID] = r
+	}, func(e *proto.NetworkResponseReceived) {
+		if r, ok := pending[e.RequestID]; ok {
+			r.Status, r.ResponseHeaders = e.Response.Status, e.Response.Headers
+		}
+	}, func(e *proto.NetworkLoadingFinished) {
+		r := finish(e.RequestID, e.Timestamp)
+		if r == nil {
+			return
+		}
+		body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(p)
+		if err != nil {
+			logrus.WithField("url", r.URL).WithError(err).Debug("NetworkGetResponseBody")
+		} else {
+			r.ResponseBody, r.Base64 = body.Body, body.Base64Encoded
+		}
+		w.Write(r)
+	}, func(e *proto.NetworkLoadingFailed) {
+		r := finish(e.RequestID, e.Timestamp)
+		if r == nil {
+			return
+		}
+		r.Error = e.ErrorText
+		if e.BlockedReason != "" {
+			r.Error += " (" + string(e.BlockedReason) + ")"
+		}
+		w.Write(r)
+	})()
+}
+
+// Write appends v as a json line.
+func (w *jsonLinesFile) Write(v interface{}) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		logrus.WithField("file", w.name).WithError(err).Error("json.Marshal")
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, err := w.f.Write(append(line, '\n')); err != nil {
+		logrus.WithField("file", w.name).WithError(err).Error("write")
+	}
+}
+
+func (w *jsonLinesFile) Close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if err := w.f.Close(); err != nil {
+		logrus.WithField("file", w.name).WithError(err).Error("close")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+)
+
+// clickCmd clicks an element of a page and prints the html once the page
+// navigated, or after the -settle delay for clicks that don't navigate.
+//
+//	ctfhelper click [-index 0] [-settle 2s] <target> <css-selector>
+func clickCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("click")
+	index := fs.Int("index", 0, "which of the matching elements to click, from 0")
+	settle := fs.Duration("settle", 2*time.Second, "how long to wait for a navigation after the click")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	selector := fs.Arg(1)
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	els, err := p.Elements(selector)
+	if err != nil {
+		return err
+	}
+	if len(els) == 0 {
+		return fmt.Errorf("no element matches %q", selector)
+	}
+	if *index < 0 || *index >= len(els) {
+		return fmt.Errorf("-index %d, but %q matches %d elements", *index, selector, len(els))
+	}
+	el := els[*index]
+
+	if err := el.ScrollIntoView(); err != nil {
+		return err
+	}
+	wait := p.Timeout(*settle).WaitNavigation(proto.PageLifecycleEventNameLoad)
+	if err := el.Click(proto.InputMouseButtonLeft); err != nil {
+		return err
+	}
+	wait()
+	if err := p.WaitLoad(); err != nil {
+		return err
+	}
+
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", html)
+	return nil
+}
+
+// firstElement is the first element of p matching selector, an error when
+// none does, where p.Element would wait for one forever.
+func firstElement(p *rod.Page, selector string) (*rod.Element, error) {
+	els, err := p.Elements(selector)
+	if err != nil {
+		return nil, err
+	}
+	if len(els) == 0 {
+		return nil, fmt.Errorf("no element matches %q", selector)
+	}
+	return els[0], nil
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var profile = flag.String("profile", "", "use the options of this `name` under profiles: in the config file")
+
+// configFiles are the optional files of flag defaults, a later one
+// overrides the keys of an earlier one.
+func configFiles() []string {
+	files := []string{}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".ctfhelper.yaml"))
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		files = append(files, filepath.Join(dir, "ctfhelper", "config.yaml"))
+	}
+	return append(files, "ctfhelper.yaml")
+}
+
+// loadConfig sets the global flags not given on the command line from the
+// config files, e.g.
+//
+//	control-url: :9333
+//	flag-regex: 'CTF\{[^}]+\}'
+//	decode: base64
+//	block:
+//	  - "*google-analytics*"
+//	  - "*hotjar*"
+//	profiles:
+//	  xss-bot:
+//	    control-url: :9444
+//	    inject: hooks/xss.js
+//	    rules: xss-rules.json
+//
+// The keys are the flag names, a list sets a repeatable flag once per item.
+// The options of the -profile profile, which the config may also name with
+// profile:, override the others.
+func loadConfig() error {
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	values := map[string][]string{}
+	for _, file := range configFiles() {
+		data, err := ioutil.ReadFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kv, err := parseConfig(file, data)
+		if err != nil {
+			return err
+		}
+		for k, v := range kv {
+			values[k] = v
+		}
+	}
+
+	name := *profile
+	if name == "" && len(values["profile"]) > 0 {
+		name = values["profile"][0]
+	}
+	options := map[string][]string{}
+	profiles := map[string]bool{}
+	for key, vs := range values {
+		path := strings.Split(key, ".")
+		switch {
+		case len(path) == 1 && path[0] != "profile":
+			options[key] = vs
+		case len(path) == 3 && path[0] == "profiles":
+			profiles[path[1]] = true
+		}
+	}
+	if name != "" {
+		if !profiles[name] {
+			return fmt.Errorf("no profile %q in the config, there are: %s", name, strings.Join(sortedKeys(profiles), ", "))
+		}
+		prefix := "profiles." + name + "."
+		for key, vs := range values {
+			if strings.HasPrefix(key, prefix) {
+				options[strings.TrimPrefix(key, prefix)] = vs
+			}
+		}
+	}
+
+	for opt, vs := range options {
+		if set[opt] {
+			continue
+		}
+		for _, v := range vs {
+			if err := flag.Set(opt, v); err != nil {
+				return fmt.Errorf("config %s: %w", opt, err)
+			}
+		}
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseConfig reads the subset of yaml loadConfig needs: `key: value` lines,
+// keys followed by `- item` lines, and nested keys, with # comments and
+// quoted values. The values are keyed by their dotted path, e.g.
+// profiles.xss-bot.control-url.
+func parseConfig(file string, data []byte) (map[string][]string, error) {
+	type parent struct {
+		indent int
+		key    string
+	}
+	var parents []parent
+	path := func(key string) string {
+		keys := []string{}
+		for _, p := range parents {
+			keys = append(keys, p.key)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+		return strings.Join(keys, ".")
+	}
+
+	values := map[string][]string{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		indent := len(sc.Text()) - len(strings.TrimLeft(sc.Text(), " "))
+
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			// items may be as indented as their key
+			for len(parents) > 0 && parents[len(parents)-1].indent > indent {
+				parents = parents[:len(parents)-1]
+			}
+			if len(parents) == 0 {
+				return nil, fmt.Errorf("%s:%d: list item without a key", file, n)
+			}
+			v, err := configValue(strings.TrimPrefix(line, "-"))
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+			}
+			values[path("")] = append(values[path("")], v)
+			continue
+		}
+
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: want key: value", file, n)
+		}
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		key := strings.TrimSpace(kv[0])
+		if err := checkConfigKey(path(key)); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		if noValue(kv[1]) {
+			// a list or nested keys follow
+			parents = append(parents, parent{indent, key})
+			continue
+		}
+		v, err := configValue(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		values[path(key)] = []string{v}
+	}
+	return values, sc.Err()
+}
+
+// checkConfigKey rejects the keys that aren't flags, or profiles of flags.
+func checkConfigKey(key string) error {
+	path := strings.Split(key, ".")
+	name := path[len(path)-1]
+	switch {
+	case path[0] == "profiles" && len(path) <= 2:
+		return nil
+	case path[0] == "profiles" && len(path) == 3:
+		if name == "profile" {
+			return fmt.Errorf("%s: a profile can't pick a profile", key)
+		}
+	case len(path) != 1:
+		return fmt.Errorf("%s: only profiles: has nested keys", key)
+	}
+	if flag.Lookup(name) == nil {
+		return fmt.Errorf("no -%s flag", name)
+	}
+	return nil
+}
+
+// configValue unquotes a value and drops its trailing comment.
+func configValue(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = s[:i]
+		}
+		if strings.HasPrefix(s, "#") {
+			s = ""
+		}
+		return strings.TrimSpace(s), nil
+	}
+
+	// the value ends at the first closing quote, what follows can only be
+	// a comment
+	end := closingQuote(s)
+	if end < 0 {
+		return "", fmt.Errorf("unterminated %s", s)
+	}
+	if rest := strings.TrimSpace(s[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", fmt.Errorf("%s: unexpected %s after the quoted value", s, rest)
+	}
+	if s[0] == '"' {
+		return strconv.Unquote(s[:end+1])
+	}
+	return strings.ReplaceAll(s[1:end], "''", "'"), nil
+}
+
+// closingQuote is the index of the quote closing the one s starts with, -1
+// if there is none. Double quoted values escape with \, single quoted ones
+// by doubling the quote.
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// noValue tells a key without a value, followed by a list or nested keys,
+// from one with an empty quoted value.
+func noValue(s string) bool {
+	s = strings.TrimSpace(s)
+	return s == "" || strings.HasPrefix(s, "#")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConfigValue(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "", want: ""},
+		{in: "  plain  ", want: "plain"},
+		{in: "plain # comment", want: "plain"},
+		{in: "a#b", want: "a#b"},
+		{in: "# only a comment", want: ""},
+		{in: `"quoted"`, want: "quoted"},
+		{in: `""`, want: ""},
+		{in: `"with # hash"`, want: "with # hash"},
+		{in: `"escaped \" quote"`, want: `escaped " quote`},
+		{in: `"tab\there"`, want: "tab\there"},
+		{in: `"value" # comment with a " quote`, want: "value"},
+		{in: `'single'`, want: "single"},
+		{in: `''`, want: ""},
+		{in: `'it''s'`, want: "it's"},
+		{in: `'value' # it's a comment`, want: "value"},
+		{in: `"unterminated`, err: true},
+		{in: `'unterminated`, err: true},
+		{in: `"value" trailing`, err: true},
+	}
+	for _, tt := range tests {
+		got, err := configValue(tt.in)
+		if (err != nil) != tt.err {
+			t.Errorf("configValue(%q) error = %v, want error %t", tt.in, err, tt.err)
+			continue
+		}
+		if err == nil && got != tt.want {
+			t.Errorf("configValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want map[string][]string
+		err  bool
+	}{
+		{
+			name: "values",
+			in:   "headless: true\nlog-level: debug # comment\n\n# comment\ntimeout: \"5s\"\n",
+			want: map[string][]string{"headless": {"true"}, "log-level": {"debug"}, "timeout": {"5s"}},
+		},
+		{
+			name: "empty quoted value",
+			in:   "user-data-dir: \"\"\nheadless: true\n",
+			want: map[string][]string{"user-data-dir": {""}, "headless": {"true"}},
+		},
+		{
+			name: "list",
+			in:   "control-url:\n  - :9222\n  - \"http://host:9223\" # second\nheadless: true\n",
+			want: map[string][]string{"control-url": {":9222", "http://host:9223"}, "headless": {"true"}},
+		},
+		{
+			name: "list as indented as its key",
+			in:   "inject:\n- a.js\n- b.js\n",
+			want: map[string][]string{"inject": {"a.js", "b.js"}},
+		},
+		{
+			name: "profiles",
+			in: "profile: xss-bot\nprofiles:\n  xss-bot:\n    headless: true\n    inject:\n      - hook.js\n" +
+				"  local:\n    control-url: :9333\nlog-level: warn\n",
+			want: map[string][]string{
+				"profile":                    {"xss-bot"},
+				"profiles.xss-bot.headless":  {"true"},
+				"profiles.xss-bot.inject":    {"hook.js"},
+				"profiles.local.control-url": {":9333"},
+				"log-level":                  {"warn"},
+			},
+		},
+		{
+			name: "profile picking a profile",
+			in:   "profiles:\n  a:\n    profile: b\n",
+			err:  true,
+		},
+		{
+			name: "nested keys outside profiles",
+			in:   "launch:\n  headless: true\n",
+			err:  true,
+		},
+		{
+			name: "list item without a key",
+			in:   "- a.js\n",
+			err:  true,
+		},
+		{
+			name: "not key: value",
+			in:   "headless\n",
+			err:  true,
+		},
+		{
+			name: "unknown flag",
+			in:   "no-such-flag: 1\n",
+			err:  true,
+		},
+		{
+			name: "unterminated quote",
+			in:   "log-level: \"debug\n",
+			err:  true,
+		},
+	}
+	for _, tt := range tests {
+		got, err := parseConfig("test.yaml", []byte(tt.in))
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckConfigKey(t *testing.T) {
+	tests := []struct {
+		key string
+		err bool
+	}{
+		{key: "headless"},
+		{key: "profile"},
+		{key: "profiles"},
+		{key: "profiles.xss-bot"},
+		{key: "profiles.xss-bot.control-url"},
+		{key: "profiles.xss-bot.profile", err: true},
+		{key: "profiles.xss-bot.no-such-flag", err: true},
+		{key: "profiles.a.b.headless", err: true},
+		{key: "launch.headless", err: true},
+		{key: "no-such-flag", err: true},
+	}
+	for _, tt := range tests {
+		if err := checkConfigKey(tt.key); (err != nil) != tt.err {
+			t.Errorf("checkConfigKey(%q) = %v, want error %t", tt.key, err, tt.err)
+		}
+	}
+}
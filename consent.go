@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+var (
+	dismissConsentFlag = flag.Bool("dismiss-consent", false, "remove cookie/consent banners after the page loads")
+	consentSelectors   = flag.String("consent-selectors", strings.Join(defaultConsentSelectors, ","),
+		"comma separated css `selectors` of the banners -dismiss-consent removes")
+)
+
+var defaultConsentSelectors = []string{
+	"#onetrust-consent-sdk",
+	"#CybotCookiebotDialog",
+	"#usercentrics-root",
+	"#truste-consent-track",
+	".qc-cmp2-container",
+	".fc-consent-root",
+	".cc-window",
+	"#cookie-banner",
+	".cookie-banner",
+	"#cookieConsent",
+	"[id*='cookie-consent']",
+	"[class*='cookie-consent']",
+}
+
+const dismissConsentJS = `(selectors) => {
+	let removed = 0
+	for (const sel of selectors) {
+		document.querySelectorAll(sel).forEach(el => { el.remove(); removed++ })
+	}
+	if (removed) {
+		document.documentElement.style.overflow = ""
+		document.body && (document.body.style.overflow = "")
+	}
+	return removed
+}`
+
+// dismissConsent removes the -consent-selectors elements from p and returns
+// how many were removed.
+func dismissConsent(p *rod.Page) (int, error) {
+	selectors := []string{}
+	for _, sel := range strings.Split(*consentSelectors, ",") {
+		if sel = strings.TrimSpace(sel); sel != "" {
+			selectors = append(selectors, sel)
+		}
+	}
+
+	res, err := p.Eval(dismissConsentJS, selectors)
+	if err != nil {
+		return 0, err
+	}
+	return res.Value.Int(), nil
+}
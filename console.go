@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var logConsole = flag.Bool("console", false, "print the console messages and uncaught exceptions of every tab, with their source and stack")
+
+// startConsole prints the console calls and uncaught exceptions of all open
+// tabs of every browser, and of the tabs opened later, what the devtools
+// console would show, also in headless runs.
+func startConsole() error {
+	return eachBrowserPage(func(p *rod.Page, browser string) {
+		go p.EachEvent(func(e *proto.RuntimeConsoleAPICalled) {
+			args := make([]string, 0, len(e.Args))
+			for _, a := range e.Args {
+				args = append(args, remoteObjectText(a))
+			}
+			msg := strings.Join(args, " ")
+			printLine(withBrowser(browser, fmt.Sprintf("[console] %s %s%s%s", e.Type, topFrame(e.StackTrace), msg, stackText(e.StackTrace))))
+			reportFlags("console", msg)
+		}, func(e *proto.RuntimeExceptionThrown) {
+			d := e.ExceptionDetails
+			msg, stack := d.Text, stackText(d.StackTrace)
+			if d.Exception != nil && d.Exception.Description != "" {
+				// the description of an Error already holds its stack
+				msg, stack = msg+" "+d.Exception.Description, ""
+			}
+			printLine(withBrowser(browser, fmt.Sprintf("[console] exception %s:%d:%d %s%s", d.URL, d.LineNumber+1, d.ColumnNumber+1, msg, stack)))
+			reportFlags("console", msg)
+		})()
+	})
+}
+
+// remoteObjectText is how the console would print a logged value.
+func remoteObjectText(o *proto.RuntimeRemoteObject) string {
+	switch {
+	case o.Type == proto.RuntimeRemoteObjectTypeString:
+		return o.Value.Str()
+	case o.UnserializableValue != "":
+		return string(o.UnserializableValue)
+	case o.Description != "":
+		return o.Description
+	case o.Type == proto.RuntimeRemoteObjectTypeUndefined:
+		return "undefined"
+	}
+	return o.Value.JSON("", "")
+}
+
+// topFrame is the "url:line:col " of where the console was called.
+func topFrame(st *proto.RuntimeStackTrace) string {
+	if st == nil || len(st.CallFrames) == 0 {
+		return ""
+	}
+	f := st.CallFrames[0]
+	return fmt.Sprintf("%s:%d:%d ", f.URL, f.LineNumber+1, f.ColumnNumber+1)
+}
+
+// stackText is the stack trace below the top frame, one frame per line.
+func stackText(st *proto.RuntimeStackTrace) string {
+	if st == nil || len(st.CallFrames) < 2 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, f := range st.CallFrames[1:] {
+		name := f.FunctionName
+		if name == "" {
+			name = "<anonymous>"
+		}
+		fmt.Fprintf(&sb, "\n    at %s (%s:%d:%d)", name, f.URL, f.LineNumber+1, f.ColumnNumber+1)
+	}
+	return sb.String()
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// cookiesCmd prints all cookies of a page's browser context, httpOnly ones
+// included, as json or as a netscape cookie jar for curl -b, ffuf or sqlmap.
+// dump saves the cookies of the page's url into a file instead, and load
+// sets the cookies of a json file again. The json holds the cookies as the
+// browser reports them, so httpOnly, sameSite, expiry and priority survive a
+// dump/load round trip.
+//
+//	ctfhelper cookies [-format json|netscape] <target>
+//	ctfhelper cookies dump [-format json|netscape] <target> <file>
+//	ctfhelper cookies load <target> <file>
+//
+// set adds cookies to the browser, from a file in either format or given as
+// Set-Cookie header values, e.g. a session stolen with an xss. They are for
+// the page's host, host-only, unless they have a Domain.
+//
+//	ctfhelper cookies set [-from-file jar] [-set 'name=value; Domain=d; Path=/']... <target>
+func cookiesCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("cookies")
+	format := fs.String("format", "json", "print or dump the cookies as `json` or netscape")
+	fromFile := fs.String("from-file", "", "set: the cookies of a json or netscape cookie jar `file`")
+	sets := stringsFlag{}
+	fs.Var(&sets, "set", "set: a cookie as a Set-Cookie `value`, like 'name=value; Domain=d; Path=/; HttpOnly', repeatable")
+	addTargetFlags(fs)
+	_ = fs.Parse(args)
+	action, rest := "", fs.Args()
+	if fs.Arg(0) == "dump" || fs.Arg(0) == "load" || fs.Arg(0) == "set" {
+		action, rest = fs.Arg(0), fs.Args()[1:]
+	}
+	parseArgs(fs, rest)
+	want := 2
+	if action == "" || action == "set" {
+		want = 1
+	}
+	if fs.NArg() != want || (action == "set" && *fromFile == "" && len(sets) == 0) {
+		return usageError(fs)
+	}
+	if *format != "json" && *format != "netscape" {
+		return fmt.Errorf("-format must be json or netscape, not %q", *format)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "":
+		res, err := proto.NetworkGetAllCookies{}.Call(p)
+		if err != nil {
+			return err
+		}
+		if *format == "netscape" {
+			fmt.Print(netscapeCookies(res.Cookies))
+			return nil
+		}
+		fmt.Println(utils.MustToJSON(res.Cookies))
+		return nil
+	case "dump":
+		cookies, err := p.Cookies(nil)
+		if err != nil {
+			return err
+		}
+		if *format == "netscape" {
+			return utils.OutputFile(outputPath(fs.Arg(1)), netscapeCookies(cookies))
+		}
+		return utils.OutputFile(outputPath(fs.Arg(1)), cookies)
+	case "set":
+		return setCookies(p, *fromFile, sets)
+	}
+	data, err := ioutil.ReadFile(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	var cookies []*proto.NetworkCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return err
+	}
+	return p.SetCookies(cookieParams(cookies))
+}
+
+// netscapeCookies formats cookies as a netscape cookie jar, the format of
+// curl's -b and -c. curl marks httpOnly cookies with a #HttpOnly_ prefix.
+func netscapeCookies(cookies []*proto.NetworkCookie) string {
+	var sb strings.Builder
+	sb.WriteString("# Netscape HTTP Cookie File\n")
+	upper := func(b bool) string { return strings.ToUpper(strconv.FormatBool(b)) }
+	for _, c := range cookies {
+		domain := c.Domain
+		if c.HTTPOnly {
+			domain = "#HttpOnly_" + domain
+		}
+		var expires int64
+		if !c.Session && c.Expires != nil {
+			expires = c.Expires.Unix()
+		}
+		fmt.Fprintf(&sb, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", domain, upper(strings.HasPrefix(c.Domain, ".")),
+			c.Path, upper(c.Secure), expires, c.Name, c.Value)
+	}
+	return sb.String()
+}
+
+// setCookies sets the cookies of the jar file, if any, and the Set-Cookie
+// values for p, then prints how many it set.
+func setCookies(p *rod.Page, file string, values []string) error {
+	var params []*proto.NetworkCookieParam
+	if file != "" {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		cookies, err := parseCookieJar(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		params = cookieParams(cookies)
+	}
+
+	host := ""
+	for _, v := range values {
+		parsed := (&http.Response{Header: http.Header{"Set-Cookie": {v}}}).Cookies()
+		if len(parsed) == 0 {
+			return fmt.Errorf("bad -set %q, want name=value; Domain=d; Path=/", v)
+		}
+		c := parsed[0]
+		param := &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HttpOnly,
+		}
+		if param.Path == "" {
+			param.Path = "/"
+		}
+		if param.Domain == "" {
+			// without a Domain attribute the cookie is host-only
+			if host == "" {
+				var err error
+				if host, err = pageHost(p); err != nil {
+					return err
+				}
+			}
+			param.URL = cookieURL(&proto.NetworkCookie{Domain: host, Path: param.Path, Secure: param.Secure})
+		}
+		switch {
+		case c.MaxAge > 0:
+			param.Expires = &proto.TimeSinceEpoch{Time: time.Now().Add(time.Duration(c.MaxAge) * time.Second)}
+		case !c.Expires.IsZero():
+			param.Expires = &proto.TimeSinceEpoch{Time: c.Expires}
+		}
+		switch c.SameSite {
+		case http.SameSiteStrictMode:
+			param.SameSite = proto.NetworkCookieSameSiteStrict
+		case http.SameSiteLaxMode:
+			param.SameSite = proto.NetworkCookieSameSiteLax
+		case http.SameSiteNoneMode:
+			param.SameSite = proto.NetworkCookieSameSiteNone
+		}
+		params = append(params, param)
+	}
+
+	if err := p.SetCookies(params); err != nil {
+		return err
+	}
+	fmt.Printf("set %d cookies\n", len(params))
+	return nil
+}
+
+// parseCookieJar reads the cookies of a json file as cookies dump writes it
+// or of a netscape cookie jar as curl -c writes it.
+func parseCookieJar(data []byte) ([]*proto.NetworkCookie, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var cookies []*proto.NetworkCookie
+		err := json.Unmarshal(trimmed, &cookies)
+		return cookies, err
+	}
+
+	var cookies []*proto.NetworkCookie
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, "\r")
+		httpOnly := strings.HasPrefix(line, "#HttpOnly_")
+		if httpOnly {
+			line = strings.TrimPrefix(line, "#HttpOnly_")
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		f := strings.Split(line, "\t")
+		if len(f) != 7 {
+			return nil, fmt.Errorf("line %d: want 7 tab separated fields, got %d", i+1, len(f))
+		}
+		expires, err := strconv.ParseInt(f[4], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: bad expiry %q", i+1, f[4])
+		}
+		// the second field tells a domain cookie from a host-only one, which
+		// the browser reports with a domain without the leading dot
+		domain := strings.TrimPrefix(f[0], ".")
+		if strings.EqualFold(f[1], "TRUE") {
+			domain = "." + domain
+		}
+		c := &proto.NetworkCookie{
+			Domain:   domain,
+			Path:     f[2],
+			Secure:   strings.EqualFold(f[3], "TRUE"),
+			Name:     f[5],
+			Value:    f[6],
+			HTTPOnly: httpOnly,
+			Session:  expires == 0,
+		}
+		if expires != 0 {
+			c.Expires = &proto.TimeSinceEpoch{Time: time.Unix(expires, 0)}
+		}
+		cookies = append(cookies, c)
+	}
+	return cookies, nil
+}
+
+// pageHost is the host of the url p shows, the default cookie domain.
+func pageHost(p *rod.Page) (string, error) {
+	info, err := p.Info()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(info.URL)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("%s has no host to set the cookie for, give its domain", info.URL)
+	}
+	return u.Hostname(), nil
+}
+
+// cookieCmd sets a single cookie for a page, e.g. a session token taken from
+// another tool, and prints the page's cookies afterwards.
+//
+//	ctfhelper cookie [-domain d] [-path /] [-http-only] [-secure] <target> <name> <value>
+func cookieCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("cookie")
+	domain := fs.String("domain", "", "cookie domain, defaults to the page's host")
+	path := fs.String("path", "/", "cookie path")
+	httpOnly := fs.Bool("http-only", false, "hide the cookie from the page's js")
+	secure := fs.Bool("secure", false, "only send the cookie over https")
+	parseArgs(fs, args)
+	if fs.NArg() != 3 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *domain == "" {
+		if *domain, err = pageHost(p); err != nil {
+			return err
+		}
+	}
+
+	err = p.SetCookies([]*proto.NetworkCookieParam{{
+		Name:     fs.Arg(1),
+		Value:    fs.Arg(2),
+		Domain:   *domain,
+		Path:     *path,
+		HTTPOnly: *httpOnly,
+		Secure:   *secure,
+	}})
+	if err != nil {
+		return err
+	}
+
+	cookies, err := p.Cookies(nil)
+	if err != nil {
+		return err
+	}
+	for _, c := range cookies {
+		fmt.Printf("%s=%s\tdomain=%s path=%s httpOnly=%t secure=%t\n", c.Name, c.Value, c.Domain, c.Path, c.HTTPOnly, c.Secure)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestParseCookieJar(t *testing.T) {
+	expires := &proto.TimeSinceEpoch{Time: time.Unix(1700000000, 0)}
+	tests := []struct {
+		name string
+		in   string
+		want []*proto.NetworkCookie
+		err  bool
+	}{
+		{
+			name: "json",
+			in:   ` [{"name":"a","value":"1","domain":".x.com","path":"/","httpOnly":true,"session":true}]`,
+			want: []*proto.NetworkCookie{{Name: "a", Value: "1", Domain: ".x.com", Path: "/", HTTPOnly: true, Session: true}},
+		},
+		{
+			name: "netscape",
+			in: "# Netscape HTTP Cookie File\n\n" +
+				".x.com\tTRUE\t/\tTRUE\t1700000000\tsid\tabc\r\n" +
+				"#HttpOnly_x.com\tFALSE\t/app\tFALSE\t0\ttok\ta=b\n",
+			want: []*proto.NetworkCookie{
+				{Name: "sid", Value: "abc", Domain: ".x.com", Path: "/", Secure: true, Expires: expires},
+				{Name: "tok", Value: "a=b", Domain: "x.com", Path: "/app", HTTPOnly: true, Session: true},
+			},
+		},
+		{
+			name: "the second field decides the leading dot",
+			in:   "x.com\tTRUE\t/\tFALSE\t0\ta\t1\n.y.com\tFALSE\t/\tFALSE\t0\tb\t2\n",
+			want: []*proto.NetworkCookie{
+				{Name: "a", Value: "1", Domain: ".x.com", Path: "/", Session: true},
+				{Name: "b", Value: "2", Domain: "y.com", Path: "/", Session: true},
+			},
+		},
+		{
+			name: "empty value",
+			in:   "x.com\tFALSE\t/\tFALSE\t0\ta\t\n",
+			want: []*proto.NetworkCookie{{Name: "a", Domain: "x.com", Path: "/", Session: true}},
+		},
+		{name: "empty", in: "# Netscape HTTP Cookie File\n"},
+		{name: "too few fields", in: "x.com\tFALSE\t/\tFALSE\t0\ta\n", err: true},
+		{name: "spaces for tabs", in: "x.com FALSE / FALSE 0 a 1\n", err: true},
+		{name: "bad expiry", in: "x.com\tFALSE\t/\tFALSE\tsoon\ta\t1\n", err: true},
+		{name: "bad json", in: "[{", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCookieJar([]byte(tt.in))
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNetscapeCookiesRoundTrip(t *testing.T) {
+	cookies := []*proto.NetworkCookie{
+		{Name: "sid", Value: "abc", Domain: ".x.com", Path: "/", Secure: true, Expires: &proto.TimeSinceEpoch{Time: time.Unix(1700000000, 0)}},
+		{Name: "tok", Value: "1", Domain: "x.com", Path: "/app", HTTPOnly: true, Session: true},
+	}
+	got, err := parseCookieJar([]byte(netscapeCookies(cookies)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, cookies) {
+		t.Errorf("got %+v, want %+v", got, cookies)
+	}
+}
+
+func TestCookieParams(t *testing.T) {
+	expires := &proto.TimeSinceEpoch{Time: time.Unix(1700000000, 0)}
+	got := cookieParams([]*proto.NetworkCookie{
+		{Name: "a", Value: "1", Domain: ".x.com", Path: "/", Expires: expires},
+		{Name: "b", Value: "2", Domain: "x.com", Path: "/app", Secure: true, Session: true, Expires: expires},
+		{Name: "c", Value: "3", Domain: "localhost", Path: "", HTTPOnly: true, Session: true},
+	})
+	want := []*proto.NetworkCookieParam{
+		{Name: "a", Value: "1", Domain: ".x.com", Path: "/", Expires: expires},
+		{Name: "b", Value: "2", URL: "https://x.com/app", Path: "/app", Secure: true},
+		{Name: "c", Value: "3", URL: "http://localhost/", HTTPOnly: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
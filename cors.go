@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// corsCmd requests url with a set of crafted Origin headers and reports how
+// the server answers each of them.
+//
+// Scripts can't set the Origin header, so the requests are sent from here
+// carrying the target page's cookies and user agent instead of via fetch.
+//
+//	ctfhelper cors <target> <url>
+func corsCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("cors")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	targetID, rawURL := fs.Arg(0), fs.Arg(1)
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if u.Host == "" {
+		return fmt.Errorf("%q is not an absolute url", rawURL)
+	}
+
+	p, err := resolveTarget(b, targetID)
+	if err != nil {
+		return err
+	}
+
+	for _, origin := range corsOrigins(u) {
+		req, err := newPageRequest(p, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Origin", origin)
+
+		res, err := noRedirectClient.Do(req)
+		if err != nil {
+			return err
+		}
+		_ = res.Body.Close()
+
+		allowOrigin := res.Header.Get("Access-Control-Allow-Origin")
+		allowCredentials := res.Header.Get("Access-Control-Allow-Credentials")
+		fmt.Printf("%-40s %d\tACAO=%q\tACAC=%q\t%s\n", origin, res.StatusCode,
+			allowOrigin, allowCredentials, corsVerdict(u, origin, allowOrigin, allowCredentials))
+	}
+	return nil
+}
+
+// corsOrigins returns the origins worth trying against u: the real one as a
+// baseline, then the usual bypasses of sloppy origin checks.
+func corsOrigins(u *url.URL) []string {
+	host := u.Hostname()
+	return []string{
+		u.Scheme + "://" + u.Host,
+		"null",
+		"https://attacker.com",
+		u.Scheme + "://evil." + host,
+		u.Scheme + "://" + host + ".attacker.com",
+		u.Scheme + "://attacker" + host,
+		"http://" + u.Host,
+	}
+}
+
+func corsVerdict(u *url.URL, origin, allowOrigin, allowCredentials string) string {
+	withCredentials := strings.EqualFold(allowCredentials, "true")
+	switch {
+	case allowOrigin == "":
+		return "-"
+	case allowOrigin == "*" && withCredentials:
+		return "MISCONFIG: wildcard with credentials"
+	case allowOrigin == "*":
+		return "wildcard"
+	case origin == u.Scheme+"://"+u.Host:
+		return "same origin"
+	case allowOrigin == origin && withCredentials:
+		return "VULNERABLE: origin reflected with credentials"
+	case allowOrigin == origin:
+		return "origin reflected"
+	default:
+		return "-"
+	}
+}
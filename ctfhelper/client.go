@@ -0,0 +1,183 @@
+// Package ctfhelper drives a running chrome over the devtools protocol the
+// way the ctfhelper command does, for use in solve scripts and harnesses.
+//
+//	c := ctfhelper.New(":9222")
+//	if err := c.Connect(); err != nil {
+//		return err
+//	}
+//	html, err := c.Navigate("admin", "https://challenge.example/admin")
+package ctfhelper
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Client is a connection to a browser. Browser can be configured, e.g. with
+// a context or a logger, before Connect.
+type Client struct {
+	// ControlURL is the browser's debugging address, such as :9222,
+	// host:9222 or a ws:// url.
+	ControlURL string
+
+	// DebuggerURL is the websocket url ControlURL resolved to, set by
+	// Connect.
+	DebuggerURL string
+
+	Browser *rod.Browser
+}
+
+// PageInfo describes an open page.
+type PageInfo struct {
+	Index    int                  `json:"index"`
+	TargetID proto.TargetTargetID `json:"targetID"`
+	URL      string               `json:"url"`
+	Title    string               `json:"title"`
+}
+
+// ErrNoPage is what the errors of Page are, when no page or more than one
+// matches the query.
+var ErrNoPage = errors.New("no single page matches")
+
+// pageError is an error of Page, its message says why no page was picked.
+type pageError string
+
+func (e pageError) Error() string { return string(e) }
+
+func (e pageError) Is(err error) bool { return err == ErrNoPage }
+
+// New returns a client for the browser at controlURL, call Connect before
+// using it.
+func New(controlURL string) *Client {
+	return &Client{ControlURL: controlURL, Browser: rod.New()}
+}
+
+// Connect resolves ControlURL and connects to the browser. A ws:// or
+// wss:// ControlURL is used as it is, gateways in front of a browser often
+// don't serve /json/version.
+func (c *Client) Connect() error {
+	u := strings.TrimSpace(c.ControlURL)
+	if !isWebSocketURL(u) {
+		var err error
+		u, err = launcher.ResolveURL(u)
+		if err != nil {
+			return err
+		}
+	}
+	c.DebuggerURL = u
+	c.Browser = c.Browser.ControlURL(u)
+	return c.Browser.Connect()
+}
+
+func isWebSocketURL(u string) bool {
+	u = strings.ToLower(u)
+	return strings.HasPrefix(u, "ws://") || strings.HasPrefix(u, "wss://")
+}
+
+// Pages lists the open pages, in the browser's order.
+func (c *Client) Pages() ([]PageInfo, error) {
+	pages, err := c.Browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+	list := []PageInfo{}
+	for i, p := range pages {
+		info, err := p.Info()
+		if err != nil {
+			// such as a crashed renderer, the other pages are still listed
+			continue
+		}
+		list = append(list, PageInfo{Index: i, TargetID: p.TargetID, URL: info.URL, Title: info.Title})
+	}
+	return list, nil
+}
+
+// Page finds the page query refers to: the page with that target id, else
+// the single page whose url or title contains query, ignoring case. A query
+// starting with ~ is a regular expression matched against urls and titles.
+func (c *Client) Page(query string) (*rod.Page, error) {
+	pages, err := c.Browser.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	needle := strings.ToLower(query)
+	match := func(s string) bool { return strings.Contains(strings.ToLower(s), needle) }
+	what := "a url or title containing it"
+	if strings.HasPrefix(query, "~") {
+		re, err := regexp.Compile(query[1:])
+		if err != nil {
+			return nil, err
+		}
+		match = re.MatchString
+		what = "a url or title matching it"
+	}
+
+	var matches []*rod.Page
+	var candidates []string
+	for _, p := range pages {
+		if string(p.TargetID) == query {
+			return p, nil
+		}
+		info, err := p.Info()
+		if err != nil {
+			continue
+		}
+		if match(info.URL) || match(info.Title) {
+			matches = append(matches, p)
+			candidates = append(candidates, fmt.Sprintf("  %s %s %q", p.TargetID, info.URL, info.Title))
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, pageError(fmt.Sprintf("no page has the id %q or %s", query, what))
+	case 1:
+		return matches[0], nil
+	}
+	return nil, pageError(fmt.Sprintf("%q matches %d pages, use one of their ids:\n%s",
+		query, len(matches), strings.Join(candidates, "\n")))
+}
+
+// DumpHTML returns the current html of the page targetID refers to, see Page.
+func (c *Client) DumpHTML(targetID string) (string, error) {
+	p, err := c.Page(targetID)
+	if err != nil {
+		return "", err
+	}
+	return HTML(p)
+}
+
+// Navigate loads url in the page targetID refers to, with the logger
+// injected, and returns the html once it loaded.
+func (c *Client) Navigate(targetID, url string) (string, error) {
+	p, err := c.Page(targetID)
+	if err != nil {
+		return "", err
+	}
+	if err := c.InjectLogger(p); err != nil {
+		return "", err
+	}
+	if err := p.Navigate(url); err != nil {
+		return "", err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return "", err
+	}
+	return HTML(p)
+}
+
+// HTML returns the current html of the page.
+func HTML(p *rod.Page) (string, error) {
+	res, err := p.Eval("document.documentElement.innerHTML")
+	if err != nil {
+		return "", err
+	}
+	return res.Value.String(), nil
+}
@@ -0,0 +1,34 @@
+package ctfhelper
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// LogPattern is the hijack pattern of the requests the injected log()
+// sends, see LogMessage.
+const LogPattern = "*/challengehelperlog*"
+
+// LoggerJS defines window.log in the page, which posts its argument back
+// through a challengehelperlog request, along with the page's target id and
+// host.
+func LoggerJS(p *rod.Page) string {
+	return fmt.Sprintf(`window.log = function log(msg){fetch("/challengehelperlog?target=%s&host="+encodeURIComponent(location.host),{method:"POST",body:msg})}`, p.TargetID)
+}
+
+// InjectLogger defines log() in every following document of the page, which
+// may belong to any browser. Hijack LogPattern to receive the messages.
+func (c *Client) InjectLogger(p *rod.Page) error {
+	_, err := p.EvalOnNewDocument(LoggerJS(p))
+	return err
+}
+
+// LogMessage is the message of a challengehelperlog request: its body, or
+// the msg parameter sent by older hooks and bookmarklets.
+func LogMessage(h *rod.Hijack) string {
+	if body := h.Request.Body(); body != "" {
+		return body
+	}
+	return h.Request.URL().Query().Get("msg")
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/gookit/color"
+)
+
+// cssPathJS defines cssPath(el), a selector that uniquely locates el.
+const cssPathJS = `const cssPath = el => {
+	const parts = []
+	while (el && el.nodeType === Node.ELEMENT_NODE) {
+		let sel = el.localName
+		if (el.id) {
+			parts.unshift(sel + "#" + CSS.escape(el.id))
+			break
+		}
+		const parent = el.parentElement
+		if (parent) {
+			const same = [...parent.children].filter(c => c.localName === el.localName)
+			if (same.length > 1) sel += ":nth-of-type(" + (same.indexOf(el) + 1) + ")"
+		}
+		parts.unshift(sel)
+		el = parent
+	}
+	return parts.join(" > ")
+}`
+
+const dataAttrsJS = `() => {
+	` + cssPathJS + `
+	const found = []
+	document.querySelectorAll("*").forEach(el => {
+		for (const attr of el.attributes) {
+			if (attr.name.startsWith("data-")) {
+				found.push({selector: cssPath(el), attribute: attr.name, value: attr.value})
+			}
+		}
+	})
+	return found
+}`
+
+type dataAttr struct {
+	Selector  string   `json:"selector"`
+	Attribute string   `json:"attribute"`
+	Value     string   `json:"value"`
+	Flags     []string `json:"flags,omitempty"`
+}
+
+// dataAttrsCmd lists every data-* attribute in the document.
+//
+//	ctfhelper data-attrs [-json] <target>
+func dataAttrsCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("data-attrs")
+	asJSON := fs.Bool("json", false, "print the attributes as a json array")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval(dataAttrsJS)
+	if err != nil {
+		return err
+	}
+	attrs := []*dataAttr{}
+	if err := unmarshalValue(res, &attrs); err != nil {
+		return err
+	}
+	for _, a := range attrs {
+		if flags := findFlags(a.Value); len(flags) > 0 {
+			a.Flags = flags
+		}
+	}
+
+	if *asJSON {
+		fmt.Println(utils.MustToJSON(attrs))
+		return nil
+	}
+	for _, a := range attrs {
+		fmt.Printf("%s\t%s\t%s\n", a.Selector, a.Attribute, a.Value)
+		for _, f := range a.Flags {
+			color.Red.Printf("flag\t%s\n", f)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+	"github.com/gookit/color"
+)
+
+// decodeScanCmd looks for long base64 and hex strings in the page source and
+// prints the ones that decode to readable text or contain a flag.
+//
+//	ctfhelper decode-scan [-min 16] <target>
+func decodeScanCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("decode-scan")
+	min := fs.Int("min", 16, "minimum length of an encoded string")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval("document.documentElement.outerHTML")
+	if err != nil {
+		return err
+	}
+	html := res.Value.String()
+
+	hexRe := regexp.MustCompile(fmt.Sprintf(`\b(?:[0-9a-fA-F]{2}){%d,}\b`, (*min+1)/2))
+	b64Re := regexp.MustCompile(fmt.Sprintf(`[A-Za-z0-9+/_-]{%d,}={0,2}`, *min))
+
+	seen := map[string]bool{}
+	report := func(kind, encoded string, decoded []byte) {
+		if seen[encoded] {
+			return
+		}
+		text := string(decoded)
+		flags := findFlags(text)
+		if len(flags) == 0 && !printable(text) {
+			return
+		}
+		seen[encoded] = true
+
+		short := encoded
+		if len(short) > 60 {
+			short = short[:57] + "..."
+		}
+		fmt.Printf("%s\t%s\t%q\n", kind, short, text)
+		for _, f := range flags {
+			color.Red.Printf("flag\t%s\n", f)
+		}
+	}
+
+	for _, s := range hexRe.FindAllString(html, -1) {
+		if decoded, err := hex.DecodeString(s); err == nil {
+			report("hex", s, decoded)
+		}
+	}
+	for _, s := range b64Re.FindAllString(html, -1) {
+		if decoded, ok := decodeBase64(s); ok {
+			report("base64", s, decoded)
+		}
+	}
+	return nil
+}
+
+// decodeBase64 tries the standard and url-safe alphabets, padded or not.
+func decodeBase64(s string) ([]byte, bool) {
+	encodings := []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding}
+	for _, enc := range encodings {
+		if decoded, err := enc.DecodeString(s); err == nil {
+			return decoded, true
+		}
+	}
+	return nil, false
+}
+
+// printable reports whether s is valid utf-8 made mostly of printable text.
+func printable(s string) bool {
+	if s == "" || !utf8.ValidString(s) {
+		return false
+	}
+	good := 0
+	for _, r := range s {
+		if unicode.IsPrint(r) || strings.ContainsRune("\t\r\n", r) {
+			good++
+		}
+	}
+	return good*10 >= utf8.RuneCountInString(s)*9
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+)
+
+// loadResponse loads the response of a hijacked request with a readable
+// body: an identity body is asked for, and one the server compresses anyway
+// is decompressed, so it can be printed, saved or patched.
+func loadResponse(h *rod.Hijack) error {
+	h.Request.Req().Header.Del("Accept-Encoding")
+	if err := h.LoadResponse(noRedirectClient, true); err != nil {
+		return err
+	}
+
+	res := h.Response
+	encoding := res.Headers().Get("Content-Encoding")
+	if encoding == "" {
+		return nil
+	}
+	body, err := decompress(res.Payload().Body, encoding)
+	if err != nil {
+		logrus.WithField("url", h.Request.URL().String()).WithError(err).Warn("keeping the compressed body")
+		return nil
+	}
+	res.SetBody(body)
+	dropHeader(res.Payload(), "Content-Encoding")
+	dropHeader(res.Payload(), "Content-Length")
+	return nil
+}
+
+// decompress undoes the Content-Encoding of a body, the codings are undone
+// in the reverse order they were applied.
+func decompress(body []byte, encoding string) ([]byte, error) {
+	codings := strings.Split(encoding, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		var r io.Reader
+		var err error
+		switch coding := strings.ToLower(strings.TrimSpace(codings[i])); coding {
+		case "", "identity":
+			continue
+		case "gzip", "x-gzip":
+			r, err = gzip.NewReader(bytes.NewReader(body))
+		case "deflate":
+			// deflate is meant to be zlib wrapped, some servers send it raw
+			r, err = zlib.NewReader(bytes.NewReader(body))
+			if err != nil {
+				r, err = flate.NewReader(bytes.NewReader(body)), nil
+			}
+		default:
+			return nil, fmt.Errorf("unsupported Content-Encoding %q", coding)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return nil, err
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// diffHTMLCmd snapshots the html of a page, waits for Enter or for an
+// element matching -wait-selector, snapshots again and prints a unified
+// diff of the two, one tag per line and whitespace normalized.
+//
+//	ctfhelper diff-html [-wait-selector css] [-timeout 30s] <target>
+func diffHTMLCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("diff-html")
+	waitSelector := fs.String("wait-selector", "", "take the second snapshot once an element matches this css selector instead of on Enter")
+	timeout := fs.Duration("timeout", 30*time.Second, "how long to wait for -wait-selector")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	before, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+
+	if *waitSelector != "" {
+		if err := waitElement(p, *waitSelector, *timeout); err != nil {
+			return err
+		}
+	} else {
+		logrus.Info("snapshot taken, press Enter for the second one")
+		if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+			return err
+		}
+	}
+
+	after, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+	fmt.Print(unifiedDiff(htmlLines(before), htmlLines(after), 3))
+	return nil
+}
+
+var spaces = regexp.MustCompile(`\s+`)
+
+// htmlLines splits html into one tag per line, with the whitespace that
+// only changes the formatting removed.
+func htmlLines(html string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(html, "><", ">\n<"), "\n") {
+		line = strings.TrimSpace(spaces.ReplaceAllString(line, " "))
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// maxDiffCells bounds the lcs table, bigger changes are shown as a whole.
+const maxDiffCells = 1 << 24
+
+// diffOps lists a as ' ' and '-' lines and b as ' ' and '+' lines, in the
+// order of a longest common subsequence.
+func diffOps(a, b []string) []string {
+	// the common ends don't need the table
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops := []string{}
+	for _, l := range a[:pre] {
+		ops = append(ops, " "+l)
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	if (len(ma)+1)*(len(mb)+1) > maxDiffCells {
+		for _, l := range ma {
+			ops = append(ops, "-"+l)
+		}
+		for _, l := range mb {
+			ops = append(ops, "+"+l)
+		}
+	} else {
+		// lcs[i][j] is the lcs length of ma[i:] and mb[j:]
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, " "+ma[i])
+				i++
+				j++
+			case j == len(mb) || (i < len(ma) && lcs[i+1][j] >= lcs[i][j+1]):
+				ops = append(ops, "-"+ma[i])
+				i++
+			default:
+				ops = append(ops, "+"+mb[j])
+				j++
+			}
+		}
+	}
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, " "+l)
+	}
+	return ops
+}
+
+// unifiedDiff formats the difference of a and b as unified diff hunks with
+// context lines around each change.
+func unifiedDiff(a, b []string, context int) string {
+	ops := diffOps(a, b)
+	var out strings.Builder
+	out.WriteString("--- before\n+++ after\n")
+
+	// line numbers in a and b at the start of each op
+	aLine, bLine := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	for i, op := range ops {
+		aLine[i+1], bLine[i+1] = aLine[i], bLine[i]
+		if op[0] != '+' {
+			aLine[i+1]++
+		}
+		if op[0] != '-' {
+			bLine[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i][0] == ' ' {
+			i++
+			continue
+		}
+		// grow the hunk while the next change is within 2*context lines
+		start := i - context
+		if start < 0 {
+			start = 0
+		}
+		end, same := i, 0
+		for end < len(ops) && same <= 2*context {
+			if ops[end][0] == ' ' {
+				same++
+			} else {
+				same = 0
+			}
+			end++
+		}
+		end -= same - context
+		if end > len(ops) {
+			end = len(ops)
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n",
+			hunkRange(aLine[start], aLine[end]-aLine[start]), hunkRange(bLine[start], bLine[end]-bLine[start]))
+		for _, op := range ops[start:end] {
+			out.WriteString(op + "\n")
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange is the start,count of a hunk header for count lines after the
+// first line. An empty range starts at the line before it, as in diff -u.
+func hunkRange(first, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", first)
+	}
+	return fmt.Sprintf("%d,%d", first+1, count)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTMLLines(t *testing.T) {
+	got := htmlLines("<div>\n  <p class=\"a\">hi   there</p><br>\n\n</div>")
+	want := []string{"<div>", `<p class="a">hi there</p>`, "<br>", "</div>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("htmlLines = %q, want %q", got, want)
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []string
+	}{
+		{name: "both empty", want: []string{}},
+		{name: "same", a: "a b c", b: "a b c", want: []string{" a", " b", " c"}},
+		{name: "all added", b: "a b", want: []string{"+a", "+b"}},
+		{name: "all removed", a: "a b", want: []string{"-a", "-b"}},
+		{name: "changed middle", a: "a b c", b: "a x c", want: []string{" a", "-b", "+x", " c"}},
+		{name: "inserted", a: "a c", b: "a b c", want: []string{" a", "+b", " c"}},
+		{name: "removed", a: "a b c", b: "a c", want: []string{" a", "-b", " c"}},
+		{name: "moved", a: "a b c d", b: "b c d a", want: []string{"-a", " b", " c", " d", "+a"}},
+		{name: "common subsequence", a: "x a y b z", b: "a q b", want: []string{"-x", " a", "-y", "+q", " b", "-z"}},
+	}
+	for _, tt := range tests {
+		got := diffOps(strings.Fields(tt.a), strings.Fields(tt.b))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: diffOps = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    string
+		context int
+		want    string
+	}{
+		{name: "no change", a: "a b", b: "a b", context: 3, want: ""},
+		{
+			name: "one change", a: "1 2 3 4 5 6 7", b: "1 2 3 x 5 6 7", context: 1,
+			want: "@@ -3,3 +3,3 @@\n 3\n-4\n+x\n 5\n",
+		},
+		{
+			name: "context clipped at the ends", a: "1 2", b: "x 2", context: 3,
+			want: "@@ -1,2 +1,2 @@\n-1\n+x\n 2\n",
+		},
+		{
+			name: "close changes share a hunk", a: "1 2 3 4 5 6", b: "x 2 3 y 5 6", context: 1,
+			want: "@@ -1,5 +1,5 @@\n-1\n+x\n 2\n 3\n-4\n+y\n 5\n",
+		},
+		{
+			name: "far changes get their own hunks", a: "1 2 3 4 5 6 7 8", b: "x 2 3 4 5 6 7 y", context: 1,
+			want: "@@ -1,2 +1,2 @@\n-1\n+x\n 2\n@@ -7,2 +7,2 @@\n 7\n-8\n+y\n",
+		},
+		{
+			name: "inserted without context", a: "1 2", b: "1 x 2", context: 0,
+			want: "@@ -1,0 +2,1 @@\n+x\n",
+		},
+		{
+			name: "added to an empty page", b: "a b", context: 3,
+			want: "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name: "all removed", a: "a b", context: 3,
+			want: "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+	}
+	for _, tt := range tests {
+		got := unifiedDiff(strings.Fields(tt.a), strings.Fields(tt.b), tt.context)
+		if want := "--- before\n+++ after\n" + tt.want; got != want {
+			t.Errorf("%s: unifiedDiff =\n%s\nwant\n%s", tt.name, got, want)
+		}
+	}
+}
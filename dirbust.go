@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+)
+
+// dirbustCmd fetches base-url+word for every word of a wordlist through the
+// target page and prints the status and size of each response.
+//
+//	ctfhelper dirbust [-w wordlist] [-c 10] [-hide 404,...] <target> <base-url>
+func dirbustCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("dirbust")
+	wordlist := fs.String("w", "", "wordlist file, one path per line (default stdin)")
+	concurrency := fs.Int("c", 10, "number of concurrent requests")
+	hide := fs.String("hide", "404", "comma separated status codes to hide")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	targetID, base := fs.Arg(0), fs.Arg(1)
+
+	hidden := map[int]bool{}
+	for _, code := range strings.Split(*hide, ",") {
+		if code = strings.TrimSpace(code); code == "" {
+			continue
+		}
+		n, err := strconv.Atoi(code)
+		if err != nil {
+			return fmt.Errorf("bad status code %q in -hide", code)
+		}
+		hidden[n] = true
+	}
+	if *concurrency < 1 {
+		*concurrency = 1
+	}
+
+	in := os.Stdin
+	if *wordlist != "" {
+		f, err := os.Open(*wordlist)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	p, err := resolveTarget(b, targetID)
+	if err != nil {
+		return err
+	}
+
+	words := make(chan string)
+	wg := sync.WaitGroup{}
+	for i := 0; i < *concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for word := range words {
+				r, err := pageFetch(p, base+word, nil)
+				if err != nil {
+					logrus.WithField("word", word).WithError(err).Error("fetch")
+					continue
+				}
+				if hidden[r.Status] {
+					continue
+				}
+				fmt.Printf("%d\t%d\t%s\n", r.Status, r.Size, base+word)
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		word := strings.TrimSpace(scanner.Text())
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		words <- word
+	}
+	close(words)
+	wg.Wait()
+
+	return scanner.Err()
+}
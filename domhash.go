@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+)
+
+// defaultNormalize strips csrf tokens and nonces up to the end of their tag.
+const defaultNormalize = `(?i)(nonce|csrf|xsrf|authenticity_token)[^>]*`
+
+// domhashCmd prints the sha256 of the page's html after removing the parts
+// matching -normalize. With -watch it keeps polling and prints a line each
+// time the hash changes.
+//
+//	ctfhelper domhash [-normalize regexp] [-watch 5s] <target>
+func domhashCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("domhash")
+	normalize := fs.String("normalize", defaultNormalize, "`regexp` of volatile markup removed before hashing, such as csrf tokens")
+	watch := fs.Duration("watch", 0, "poll at this interval and print whenever the hash changes")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	re, err := regexp.Compile(*normalize)
+	if err != nil {
+		return err
+	}
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	last := ""
+	for {
+		sum, err := domHash(p, re)
+		if err != nil {
+			return err
+		}
+		if sum != last {
+			if *watch > 0 {
+				fmt.Printf("%s %s\n", time.Now().Format(time.RFC3339), sum)
+			} else {
+				fmt.Println(sum)
+			}
+			last = sum
+		}
+		if *watch <= 0 {
+			return nil
+		}
+		time.Sleep(*watch)
+	}
+}
+
+func domHash(p *rod.Page, normalize *regexp.Regexp) (string, error) {
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return "", err
+	}
+	html = normalize.ReplaceAllString(html, "")
+	sum := sha256.Sum256([]byte(html))
+	return hex.EncodeToString(sum[:]), nil
+}
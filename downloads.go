@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// downloadsCmd saves the files a page downloads into a directory, and prints
+// the path and size of each one once complete, until the tool is
+// interrupted. The directory is on the browser's machine.
+//
+//	ctfhelper downloads -dir <dir> <target>
+func downloadsCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("downloads")
+	dir := fs.String("dir", "", "directory the downloads are saved in")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || *dir == "" {
+		return usageError(fs)
+	}
+	// chrome wants an absolute path
+	abs, err := filepath.Abs(*dir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		return err
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	err = proto.BrowserSetDownloadBehavior{
+		Behavior:     proto.BrowserSetDownloadBehaviorBehaviorAllowAndName,
+		DownloadPath: abs,
+	}.Call(b)
+	if err != nil {
+		return err
+	}
+	logrus.WithField("dir", abs).Info("waiting for downloads")
+
+	// allowAndName saves a download under its guid, it gets its suggested
+	// name once complete
+	names := map[string]string{}
+	p.EachEvent(func(e *proto.PageDownloadWillBegin) {
+		names[e.GUID] = e.SuggestedFilename
+		logrus.WithFields(logrus.Fields{"url": e.URL, "name": e.SuggestedFilename}).Info("download started")
+	}, func(e *proto.PageDownloadProgress) {
+		switch e.State {
+		case proto.PageDownloadProgressStateCompleted:
+			path := savedDownload(abs, e.GUID, names[e.GUID])
+			printLine(fmt.Sprintf("%s %d", path, int64(e.ReceivedBytes)))
+			delete(names, e.GUID)
+		case proto.PageDownloadProgressStateCanceled:
+			logrus.WithField("name", names[e.GUID]).Warn("download canceled")
+			delete(names, e.GUID)
+		}
+	})()
+	return nil
+}
+
+// savedDownload renames the completed download guid to name, unless a file
+// already has that name, and returns its path.
+func savedDownload(dir, guid, name string) string {
+	path := filepath.Join(dir, guid)
+	name = filepath.Base(name)
+	if name == "." || name == string(filepath.Separator) {
+		return path
+	}
+	named := filepath.Join(dir, name)
+	if _, err := os.Stat(named); err == nil {
+		return path
+	}
+	if err := os.Rename(path, named); err != nil {
+		logrus.WithError(err).Debug("rename download")
+		return path
+	}
+	return named
+}
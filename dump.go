@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// selectorHTMLJS returns the outerHTML of the first element matching sel, or
+// of all of them, null when none matches.
+const selectorHTMLJS = `(sel, all) => {
+	if (!all) {
+		const el = document.querySelector(sel)
+		return el ? el.outerHTML : null
+	}
+	const els = Array.from(document.querySelectorAll(sel), el => el.outerHTML)
+	return els.length ? els.join("\n") : null
+}`
+
+// dumpCmd prints the html of a page, given by id or by a part of its url or
+// title (see resolveTarget). With -selector only the matching subtree is
+// printed, and with -out it's written to a file rather than stdout.
+//
+//	ctfhelper dump [-selector css [-all]] [-out file] <target>
+func dumpCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("dump")
+	selector := fs.String("selector", "", "only print the outerHTML of the first element matching this css selector")
+	all := fs.Bool("all", false, "with -selector, print every matching element")
+	out := fs.String("out", "", "write the html to this `file` instead of stdout")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *dismissConsentFlag {
+		if _, err := dismissConsent(p); err != nil {
+			logrus.WithError(err).Error("dismissConsent")
+		}
+	}
+	var html string
+	if *selector != "" {
+		res, err := p.Eval(selectorHTMLJS, *selector, *all)
+		if err != nil {
+			return err
+		}
+		if res.Value.Nil() {
+			return fmt.Errorf("no element matches %q", *selector)
+		}
+		html = res.Value.String()
+	} else if html, err = ctfhelper.HTML(p); err != nil {
+		return err
+	}
+	reportFlags("dump", html)
+	if *out == "" {
+		fmt.Printf("%s\n", html)
+		return nil
+	}
+	file := outputPath(*out)
+	if err := utils.OutputFile(file, html); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d bytes to %s\n", len(html), file)
+	return nil
+}
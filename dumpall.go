@@ -0,0 +1,73 @@
+package main
+
+import (
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// dumpedPage is an entry of the manifest.json written by dump-all.
+type dumpedPage struct {
+	TargetID proto.TargetTargetID `json:"targetID"`
+	URL      string               `json:"url"`
+	File     string               `json:"file,omitempty"`
+	Error    string               `json:"error,omitempty"`
+}
+
+// dumpAllCmd saves the html of every open page into dir as <targetID>.html,
+// with a manifest.json mapping them to their urls. At most -c pages are
+// dumped at the same time.
+//
+//	ctfhelper dump-all [-c 5] -out-dir <dir>
+func dumpAllCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("dump-all")
+	dir := fs.String("out-dir", "", "directory to write the pages into, defaults to -output-dir")
+	concurrency := fs.Int("c", 5, "pages dumped at the same time")
+	parseArgs(fs, args)
+	if *dir == "" {
+		*dir = *outputDir
+	}
+	if fs.NArg() != 0 || *dir == "" || *concurrency < 1 {
+		return usageError(fs)
+	}
+
+	pages, err := b.Pages()
+	if err != nil {
+		return err
+	}
+
+	manifest := make([]dumpedPage, len(pages))
+	sem := make(chan struct{}, *concurrency)
+	actions := []func(){}
+	for i, p := range pages {
+		i, p := i, p
+		actions = append(actions, func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entry := dumpedPage{TargetID: p.TargetID}
+			defer func() { manifest[i] = entry }()
+
+			if info, err := p.Info(); err == nil {
+				entry.URL = info.URL
+			}
+			html, err := ctfhelper.HTML(p)
+			if err == nil {
+				name := string(p.TargetID) + ".html"
+				err = utils.OutputFile(filepath.Join(*dir, name), html)
+				entry.File = name
+			}
+			if err != nil {
+				logrus.WithField("target", p.TargetID).WithError(err).Error("dump-all")
+				entry.File, entry.Error = "", err.Error()
+			}
+		})
+	}
+	utils.All(actions...)()
+
+	return utils.OutputFile(filepath.Join(*dir, "manifest.json"), manifest)
+}
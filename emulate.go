@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// deviceList are the devices navigate's -device picks from, by their
+// deviceName.
+var deviceList = []devices.Device{
+	devices.IPhone4, devices.IPhone5orSE, devices.IPhone6or7or8, devices.IPhone6or7or8Plus, devices.IPhoneX,
+	devices.BlackBerryZ30, devices.Nexus4, devices.Nexus5, devices.Nexus5X, devices.Nexus6, devices.Nexus6P,
+	devices.Pixel2, devices.Pixel2XL, devices.LGOptimusL70, devices.NokiaN9, devices.NokiaLumia520,
+	devices.MicrosoftLumia550, devices.MicrosoftLumia950, devices.GalaxySIII, devices.GalaxyS5, devices.JioPhone2,
+	devices.KindleFireHDX, devices.IPadMini, devices.IPad, devices.IPadPro, devices.BlackberryPlayBook,
+	devices.Nexus10, devices.Nexus7, devices.GalaxyNote3, devices.GalaxyNoteII, devices.LaptopWithTouch,
+	devices.LaptopWithHiDPIScreen, devices.LaptopWithMDPIScreen, devices.MotoG4, devices.SurfaceDuo, devices.GalaxyFold,
+}
+
+// deviceName is the lowercase, dashed title of a device, e.g. iphone-x for
+// "iPhone X".
+func deviceName(d devices.Device) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(d.Get("title").Str()), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "-")
+}
+
+// lookupDevice finds a device by its deviceName.
+func lookupDevice(name string) (devices.Device, error) {
+	names := []string{}
+	for _, d := range deviceList {
+		if deviceName(d) == strings.ToLower(name) {
+			return d, nil
+		}
+		names = append(names, deviceName(d))
+	}
+	sort.Strings(names)
+	return devices.Device{}, fmt.Errorf("unknown -device %q, want one of %s", name, strings.Join(names, ", "))
+}
+
+// parseGeo parses a -geo "lat,lng" value.
+func parseGeo(value string) (*proto.EmulationSetGeolocationOverride, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("-geo %q: want lat,lng", value)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil, fmt.Errorf("-geo %q: bad latitude", value)
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return nil, fmt.Errorf("-geo %q: bad longitude", value)
+	}
+	return &proto.EmulationSetGeolocationOverride{Latitude: lat, Longitude: lng, Accuracy: 10}, nil
+}
+
+// emulateGeo makes the page's geolocation api report geo, without a
+// permission prompt.
+func emulateGeo(b *rod.Browser, p *rod.Page, geo *proto.EmulationSetGeolocationOverride) error {
+	err := proto.BrowserGrantPermissions{
+		Permissions: []proto.BrowserPermissionType{proto.BrowserPermissionTypeGeolocation},
+	}.Call(b)
+	if err != nil {
+		return err
+	}
+	return geo.Call(p)
+}
+
+// checkEmulation warns when the loaded page doesn't see the emulated user
+// agent or position.
+func checkEmulation(p *rod.Page, ua *proto.NetworkSetUserAgentOverride, geo *proto.EmulationSetGeolocationOverride) {
+	if ua != nil {
+		res, err := p.Eval(`() => navigator.userAgent`)
+		if err != nil {
+			logrus.WithError(err).Error("checkEmulation")
+		} else if res.Value.Str() != ua.UserAgent {
+			logrus.WithField("userAgent", res.Value.Str()).Warn("the page doesn't see the emulated user agent")
+		}
+	}
+	if geo != nil {
+		res, err := p.Timeout(5 * time.Second).Eval(`() => new Promise(resolve => navigator.geolocation.getCurrentPosition(
+			pos => resolve([pos.coords.latitude, pos.coords.longitude]), () => resolve(null)))`)
+		if err != nil {
+			logrus.WithError(err).Error("checkEmulation")
+			return
+		}
+		var pos []float64
+		if err := unmarshalValue(res, &pos); err != nil || len(pos) != 2 || pos[0] != geo.Latitude || pos[1] != geo.Longitude {
+			logrus.WithField("position", res.Value.JSON("", "")).Warn("the page doesn't see the emulated position")
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// evalFileCmd runs a js file in a page and prints the value of its last
+// statement, or with -new-document registers it to run at the start of
+// every following document. The file is evaluated as a script rather than
+// through Eval, which only takes a single expression or function, and
+// errors point at the file's line.
+//
+//	ctfhelper eval-file [-new-document] <target> <script.js>
+func evalFileCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("eval-file")
+	newDocument := fs.Bool("new-document", false, "run the script in every following document instead of now")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	file := fs.Arg(1)
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	script, err := utils.ReadString(file)
+	if err != nil {
+		return err
+	}
+	script += "\n//# sourceURL=" + filepath.Base(file)
+
+	if *newDocument {
+		_, err := p.EvalOnNewDocument(script)
+		return err
+	}
+
+	res, err := proto.RuntimeEvaluate{
+		Expression:    script,
+		ReturnByValue: true,
+		AwaitPromise:  true,
+	}.Call(p)
+	if err != nil {
+		return err
+	}
+	if e := res.ExceptionDetails; e != nil {
+		msg := e.Text
+		if e.Exception != nil && e.Exception.Description != "" {
+			msg = e.Exception.Description
+		}
+		return fmt.Errorf("%s:%d:%d: %s", file, e.LineNumber+1, e.ColumnNumber+1, msg)
+	}
+	fmt.Println(res.Result.Value.String())
+	return nil
+}
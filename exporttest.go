@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// exportTestCmd turns a -record session into a go test that replays the
+// navigations, dumps and asserts with the rod api, and fails unless the last
+// dump contains a -flag-regex match. Each page is opened at the url it had
+// when recorded.
+//
+//	ctfhelper export-test [-session file] [-name Solve] <out_test.go>
+func exportTestCmd(_ *rod.Browser, args []string) error {
+	fs := newFlagSet("export-test")
+	session := fs.String("session", "session.jsonl", "session `file` written with -record")
+	name := fs.String("name", "Solve", "the test is named Test<name>")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	calls, err := readRecordedCalls(*session)
+	if err != nil {
+		return err
+	}
+
+	code, err := solveTest(*name, calls)
+	if err != nil {
+		return err
+	}
+	return utils.OutputFile(fs.Arg(0), code)
+}
+
+func solveTest(name string, calls []recordedCall) ([]byte, error) {
+	body := &bytes.Buffer{}
+	w := func(format string, a ...interface{}) { fmt.Fprintf(body, format+"\n", a...) }
+	imports := []string{"regexp", "testing"}
+
+	w("func Test%s(t *testing.T) {", name)
+	w("\tb := rod.New().ControlURL(launcher.MustResolveURL(%q)).MustConnect()", controlURL())
+	w("\thtml := \"\"")
+
+	flagRegex := ""
+	pages := map[string]string{}
+	// page opens the page of a target at the url it had when recorded
+	page := func(target, u string) string {
+		v, ok := pages[target]
+		if !ok {
+			v = fmt.Sprintf("p%d", len(pages))
+			pages[target] = v
+			w("\t%s := b.MustPage(%q).MustWaitLoad() // %s", v, u, target)
+			w("\tdefer %s.MustClose()", v)
+		}
+		return v
+	}
+
+	for _, c := range calls {
+		if c.FlagRegex != "" {
+			flagRegex = c.FlagRegex
+		}
+		w("")
+		w("\t// ctfhelper %s", strings.Join(c.Args, " "))
+		cmd, params := c.Command, c.Params
+		if cmd == "" && params == nil {
+			// sessions recorded before the params were
+			params = c.Args
+		}
+		if cmd == "" {
+			cmd = map[int]string{1: "dump", 2: "navigate"}[len(params)]
+		}
+		switch {
+		case cmd == "dump" && len(params) == 1:
+			w("\thtml = %s", pageHTML(page(params[0], c.TargetURL), c.Options["selector"]))
+			skippedOptions(w, c.Options, "selector")
+		case cmd == "navigate" && len(params) == 2:
+			p := page(params[0], c.TargetURL)
+			w("\t%s.MustNavigate(%q).MustWaitLoad()", p, params[1])
+			if sel := c.Options["wait-selector"]; sel != "" {
+				w("\t%s.MustElement(%q)", p, sel)
+			}
+			w("\thtml = %s", pageHTML(p, ""))
+			skippedOptions(w, c.Options, "wait-selector")
+		case cmd == "assert" && len(params) == 1 && c.Options["selector"] != "":
+			sel, contains := c.Options["selector"], c.Options["contains"]
+			w("\tif text := %s.MustElement(%q).MustText(); !strings.Contains(text, %q) {", page(params[0], c.TargetURL), sel, contains)
+			w("\t\tt.Fatalf(\"%%q doesn't contain %%q, its text is %%q\", %q, %q, text)", sel, contains)
+			w("\t}")
+			if !containsString(imports, "strings") {
+				imports = append(imports, "strings")
+			}
+		case cmd == "assert" && len(params) == 1 && c.Options["eval"] != "":
+			expr, expect := c.Options["eval"], c.Options["expect"]
+			// like assert, strings compare as they are, other values as json
+			w("\t{")
+			w("\t\tv := %s.MustEval(%q)", page(params[0], c.TargetURL), expr)
+			w("\t\tgot := v.JSON(\"\", \"\")")
+			w("\t\tif s, ok := v.Val().(string); ok {")
+			w("\t\t\tgot = s")
+			w("\t\t}")
+			w("\t\tif got != %q {", expect)
+			w("\t\t\tt.Fatalf(\"%%s is %%s, want %%s\", %q, got, %q)", expr, expect)
+			w("\t\t}")
+			w("\t}")
+		default:
+			w("\t// not reproducible with the rod api, skipped")
+		}
+	}
+
+	w("")
+	w("\tflag := regexp.MustCompile(%q).FindString(html)", flagRegex)
+	w("\tif flag == \"\" {")
+	w("\t\tt.Fatal(\"no flag in the final page\")")
+	w("\t}")
+	w("\tt.Log(flag)")
+	w("}")
+
+	out := &bytes.Buffer{}
+	fmt.Fprintln(out, "package solve")
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "import (")
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(out, "\t%q\n", imp)
+	}
+	fmt.Fprintln(out, "")
+	fmt.Fprintln(out, "\t\"github.com/go-rod/rod\"")
+	fmt.Fprintln(out, "\t\"github.com/go-rod/rod/lib/launcher\"")
+	fmt.Fprintln(out, ")")
+	fmt.Fprintln(out, "")
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+// pageHTML is the go expression of the html of page variable p, or of its
+// first element matching selector.
+func pageHTML(p, selector string) string {
+	if selector != "" {
+		return fmt.Sprintf("%s.MustElement(%q).MustHTML()", p, selector)
+	}
+	return p + `.MustEval("document.documentElement.innerHTML").String()`
+}
+
+// skippedOptions notes the options of a call the test doesn't reproduce.
+func skippedOptions(w func(string, ...interface{}), options map[string]string, reproduced ...string) {
+	names := []string{}
+	for name := range options {
+		if !containsString(reproduced, name) {
+			names = append(names, "-"+name)
+		}
+	}
+	if len(names) > 0 {
+		sort.Strings(names)
+		w("\t// %s not reproduced", strings.Join(names, " "))
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+)
+
+var fakeTime = flag.String("fake-time", "", "make the navigated page believe the current time is this `RFC3339` timestamp")
+
+// fakeTimeJS shifts Date, Date.now and performance.timeOrigin by a fixed
+// offset in milliseconds, so the clock keeps ticking from the fake time.
+const fakeTimeJS = `(() => {
+	const offset = %d
+	const RealDate = Date
+	function FakeDate(...args) {
+		if (!new.target) return new FakeDate().toString()
+		if (args.length === 0) return new RealDate(RealDate.now() + offset)
+		return new RealDate(...args)
+	}
+	FakeDate.prototype = RealDate.prototype
+	FakeDate.prototype.constructor = FakeDate
+	FakeDate.now = () => RealDate.now() + offset
+	FakeDate.parse = RealDate.parse
+	FakeDate.UTC = RealDate.UTC
+	window.Date = FakeDate
+	const timeOrigin = performance.timeOrigin + offset
+	Object.defineProperty(performance, "timeOrigin", {get: () => timeOrigin})
+})()`
+
+// applyFakeTime registers the Date patch on p for every following document.
+func applyFakeTime(p *rod.Page, value string) error {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return err
+	}
+	offset := t.Sub(time.Now()).Milliseconds()
+	_, err = p.EvalOnNewDocument(fmt.Sprintf(fakeTimeJS, offset))
+	return err
+}
+
+// checkFakeTime warns when the clocks the page can read disagree with each
+// other, which means the patch did not apply to the loaded document.
+func checkFakeTime(p *rod.Page, value string) {
+	res, err := p.Eval(`() => [Date.now(), new Date().getTime(), performance.timeOrigin + performance.now()]`)
+	if err != nil {
+		logrus.WithError(err).Error("checkFakeTime")
+		return
+	}
+
+	var clocks []float64
+	if err := unmarshalValue(res, &clocks); err != nil || len(clocks) != 3 {
+		logrus.WithError(err).Error("checkFakeTime")
+		return
+	}
+
+	want, _ := time.Parse(time.RFC3339, value)
+	for _, c := range clocks {
+		if math.Abs(c-clocks[0]) > 1000 {
+			logrus.WithField("clocks", clocks).Warn("fake time is inconsistent across Date and performance")
+			return
+		}
+	}
+	logrus.WithField("now", time.Unix(0, int64(clocks[0])*int64(time.Millisecond)).UTC()).
+		WithField("want", want.UTC()).Debug("fake time applied")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// fetchResult is what pageFetch reads back from the page's fetch call.
+type fetchResult struct {
+	Status  int               `json:"status"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Size    int               `json:"size"`
+	Body    string            `json:"body"`
+}
+
+const fetchJS = `(url, init) => fetch(url, Object.assign({credentials: "include"}, init)).then(async r => {
+	const buf = await r.arrayBuffer()
+	const headers = {}
+	r.headers.forEach((v, k) => headers[k] = v)
+	return {status: r.status, url: r.url, headers, size: buf.byteLength, body: new TextDecoder().decode(buf)}
+})`
+
+// pageFetch requests url with the page's own fetch, so the page's cookies,
+// origin and service workers apply. init is passed as fetch's second argument.
+func pageFetch(p *rod.Page, url string, init map[string]interface{}) (*fetchResult, error) {
+	res, err := p.Eval(fetchJS, url, init)
+	if err != nil {
+		return nil, err
+	}
+	var r fetchResult
+	if err := unmarshalValue(res, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// unmarshalValue decodes the json value of an eval result into out.
+func unmarshalValue(obj *proto.RuntimeRemoteObject, out interface{}) error {
+	return json.Unmarshal([]byte(obj.Value.JSON("", "")), out)
+}
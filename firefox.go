@@ -0,0 +1,25 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Firefox (started with --remote-debugging-port) speaks only part of CDP.
+// Listing targets, dumping, navigating and evaluating work, but it has no
+// Fetch domain, so request hijacking, and with it the log() bridge, is off.
+var browserName = flag.String("browser", "chrome", "browser behind the control url: chrome or firefox (experimental)")
+
+func checkBrowserName() error {
+	switch *browserName {
+	case "chrome", "firefox":
+		return nil
+	default:
+		return fmt.Errorf("unknown -browser %q, want chrome or firefox", *browserName)
+	}
+}
+
+// canHijack reports whether the browser supports request interception.
+func canHijack() bool {
+	return *browserName != "firefox"
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/gookit/color"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultFlagRegex = `[A-Za-z0-9_]+\{[^}]+\}`
+
+var flagRegex = flag.String("flag-regex", defaultFlagRegex, "`regexp` matching the ctf flag format")
+
+func init() {
+	flag.StringVar(flagRegex, "grep-flag", defaultFlagRegex, "same as -flag-regex")
+}
+
+var (
+	reportedLock sync.Mutex
+	reported     = map[string]bool{}
+)
+
+// reportFlags highlights on stderr the flags found in text the first time
+// any channel shows them, so they stand out of dumps and log streams
+// without breaking what's piped from stdout.
+func reportFlags(channel, text string) {
+	for _, f := range findFlags(text) {
+		reportedLock.Lock()
+		seen := reported[f]
+		reported[f] = true
+		reportedLock.Unlock()
+		if !seen {
+			fmt.Fprintln(os.Stderr, color.Red.Sprintf("FLAG (%s) %s", channel, f))
+		}
+	}
+}
+
+// findFlags returns the distinct substrings of s matching -flag-regex.
+func findFlags(s string) []string {
+	re, err := regexp.Compile(*flagRegex)
+	if err != nil {
+		logrus.WithError(err).Error("bad -flag-regex")
+		return nil
+	}
+
+	found := []string{}
+	seen := map[string]bool{}
+	for _, f := range re.FindAllString(s, -1) {
+		if !seen[f] {
+			seen[f] = true
+			found = append(found, f)
+		}
+	}
+	return found
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+)
+
+// fieldKindJS tells how a form control has to be filled.
+const fieldKindJS = `() => this.tagName === "SELECT" ? "select" :
+	this.type === "checkbox" ? "checkbox" :
+	this.type === "radio" ? "radio" : "text"`
+
+const selectValueJS = `(value) => {
+	if (![...this.options].some(o => o.value === value)) return false
+	this.value = value
+	this.dispatchEvent(new Event("input", {bubbles: true}))
+	this.dispatchEvent(new Event("change", {bubbles: true}))
+	return true
+}`
+
+// submitFormJS clicks the submit button of the element's form, so its
+// onclick handlers run too, or submits the form when it has none.
+const submitFormJS = `() => {
+	const form = this.form || this.closest("form")
+	if (!form) return false
+	const button = form.querySelector("[type=submit], button:not([type])")
+	if (button) button.click()
+	else form.submit()
+	return true
+}`
+
+// formCmd fills the named fields of a page's form, and with -submit submits
+// it and prints the html of the response.
+//
+//	ctfhelper form [-field name=value]... [-submit] [-timeout 5s] <target>
+func formCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("form")
+	var fields stringsFlag
+	fs.Var(&fields, "field", "`name=value` to fill in, repeatable; checkboxes take true/false, radios and selects a value")
+	submit := fs.Bool("submit", false, "submit the form of the first field and print the resulting html")
+	timeout := fs.Duration("timeout", 5*time.Second, "how long to wait for each field and for the submit's navigation")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || len(fields) == 0 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var first *rod.Element
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("-field %q: want name=value", field)
+		}
+		el, err := fillField(p.Timeout(*timeout), kv[0], kv[1])
+		if err != nil {
+			return fmt.Errorf("field %s: %w", kv[0], err)
+		}
+		if first == nil {
+			first = el
+		}
+	}
+	if !*submit {
+		return nil
+	}
+
+	wait := p.Timeout(*timeout).WaitNavigation(proto.PageLifecycleEventNameLoad)
+	res, err := first.Eval(submitFormJS)
+	if err != nil {
+		return err
+	}
+	if !res.Value.Bool() {
+		return fmt.Errorf("field %s is not in a form", strings.SplitN(fields[0], "=", 2)[0])
+	}
+	// forms submitted by xhr don't navigate, then the wait just times out
+	wait()
+	if err := p.WaitLoad(); err != nil {
+		return err
+	}
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", html)
+	return nil
+}
+
+// fillField sets the control called name to value the way a user would:
+// typing into text inputs, picking an option, or clicking checkboxes and
+// radios.
+func fillField(p *rod.Page, name, value string) (*rod.Element, error) {
+	el, err := p.Element("[name=" + strconv.Quote(name) + "]")
+	if err != nil {
+		return nil, err
+	}
+	kind, err := el.Eval(fieldKindJS)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind.Value.Str() {
+	case "select":
+		res, err := el.Eval(selectValueJS, value)
+		if err != nil {
+			return nil, err
+		}
+		if !res.Value.Bool() {
+			return nil, fmt.Errorf("no option has the value %q", value)
+		}
+	case "checkbox":
+		want, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		checked, err := el.Property("checked")
+		if err != nil {
+			return nil, err
+		}
+		if checked.Bool() != want {
+			return el, el.Click(proto.InputMouseButtonLeft)
+		}
+	case "radio":
+		el, err = p.Element("[name=" + strconv.Quote(name) + "][value=" + strconv.Quote(value) + "]")
+		if err != nil {
+			return nil, err
+		}
+		return el, el.Click(proto.InputMouseButtonLeft)
+	default:
+		if err := el.SelectAllText(); err != nil {
+			return nil, err
+		}
+		if err := el.Input(value); err != nil {
+			return nil, err
+		}
+	}
+	return el, nil
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// The HAR 1.2 format, only the parts ctfhelper fills in.
+// http://www.softwareishard.com/blog/har-12-spec/
+type (
+	harFile struct {
+		Log harLog `json:"log"`
+	}
+	harLog struct {
+		Version string      `json:"version"`
+		Creator harCreator  `json:"creator"`
+		Entries []*harEntry `json:"entries"`
+	}
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	harEntry struct {
+		StartedDateTime time.Time   `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+	}
+	harRequest struct {
+		Method      string       `json:"method"`
+		URL         string       `json:"url"`
+		HTTPVersion string       `json:"httpVersion"`
+		Cookies     []harNV      `json:"cookies"`
+		Headers     []harNV      `json:"headers"`
+		QueryString []harNV      `json:"queryString"`
+		PostData    *harPostData `json:"postData,omitempty"`
+		HeadersSize int          `json:"headersSize"`
+		BodySize    int          `json:"bodySize"`
+	}
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+	harResponse struct {
+		Status      int        `json:"status"`
+		StatusText  string     `json:"statusText"`
+		HTTPVersion string     `json:"httpVersion"`
+		Cookies     []harNV    `json:"cookies"`
+		Headers     []harNV    `json:"headers"`
+		Content     harContent `json:"content"`
+		RedirectURL string     `json:"redirectURL"`
+		HeadersSize int        `json:"headersSize"`
+		BodySize    int        `json:"bodySize"`
+	}
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text,omitempty"`
+		Encoding string `json:"encoding,omitempty"`
+	}
+	harTimings struct {
+		Blocked float64 `json:"blocked"`
+		DNS     float64 `json:"dns"`
+		Connect float64 `json:"connect"`
+		SSL     float64 `json:"ssl"`
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+	harNV struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+)
+
+// harCmd records the traffic of a page for a while and writes it as a HAR
+// file, response bodies included when the browser still has them.
+//
+//	ctfhelper har [-duration 10s] <target> <out.har>
+func harCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("har")
+	duration := fs.Duration("duration", 10*time.Second, "how long to record")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	stop := recordHAR(p)
+	logrus.WithField("duration", *duration).Info("recording")
+	select {
+	case <-time.After(*duration):
+	case <-p.GetContext().Done():
+	}
+	return utils.OutputFile(outputPath(fs.Arg(1)), stop())
+}
+
+// recordHAR starts recording the traffic of p. stop ends the recording and
+// returns what it got. A redirect is an entry of its own, its response
+// pointing at the next one.
+func recordHAR(p *rod.Page) (stop func() harFile) {
+	entries := []*harEntry{}
+	byID := map[proto.NetworkRequestID]*harEntry{}
+	timing := map[proto.NetworkRequestID]*proto.NetworkResourceTiming{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wait := p.Context(ctx).EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		// the redirected request had the same id
+		if prev, ok := byID[e.RequestID]; ok && e.RedirectResponse != nil {
+			prev.Response = harResponseOf(e.RedirectResponse)
+			prev.Response.RedirectURL = e.Request.URL
+			prev.Timings = harTimingsOf(e.RedirectResponse.Timing, e.Timestamp)
+			prev.Time = prev.Timings.total()
+		}
+		entry := &harEntry{StartedDateTime: time.Now(), Request: harRequestOf(e.Request)}
+		if e.WallTime != nil {
+			entry.StartedDateTime = e.WallTime.Time
+		}
+		entries = append(entries, entry)
+		byID[e.RequestID] = entry
+	}, func(e *proto.NetworkResponseReceived) {
+		entry, ok := byID[e.RequestID]
+		if !ok {
+			return
+		}
+		entry.Response = harResponseOf(e.Response)
+		timing[e.RequestID] = e.Response.Timing
+	}, func(e *proto.NetworkLoadingFinished) {
+		entry, ok := byID[e.RequestID]
+		if !ok {
+			return
+		}
+		delete(byID, e.RequestID)
+		entry.Response.BodySize = int(e.EncodedDataLength)
+		entry.Timings = harTimingsOf(timing[e.RequestID], e.Timestamp)
+		entry.Time = entry.Timings.total()
+
+		body, err := proto.NetworkGetResponseBody{RequestID: e.RequestID}.Call(p)
+		if err != nil {
+			logrus.WithField("url", entry.Request.URL).WithError(err).Debug("NetworkGetResponseBody")
+			return
+		}
+		entry.Response.Content.Text = body.Body
+		entry.Response.Content.Size = len(body.Body)
+		if body.Base64Encoded {
+			entry.Response.Content.Encoding = "base64"
+			entry.Response.Content.Size = base64.StdEncoding.DecodedLen(len(body.Body))
+		}
+	})
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	return func() harFile {
+		cancel()
+		<-done
+
+		// requests that never got an answer would make an invalid har
+		answered := []*harEntry{}
+		for _, entry := range entries {
+			if entry.Response.Status != 0 {
+				answered = append(answered, entry)
+			}
+		}
+		return harFile{harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "ctfhelper", Version: "1"},
+			Entries: answered,
+		}}
+	}
+}
+
+func harRequestOf(r *proto.NetworkRequest) harRequest {
+	req := harRequest{
+		Method:      r.Method,
+		URL:         r.URL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     harCookies(r.Headers, "Cookie"),
+		Headers:     harHeaders(r.Headers),
+		QueryString: []harNV{},
+		HeadersSize: -1,
+		BodySize:    len(r.PostData),
+	}
+	if u, err := url.Parse(r.URL); err == nil {
+		for name, values := range u.Query() {
+			for _, v := range values {
+				req.QueryString = append(req.QueryString, harNV{name, v})
+			}
+		}
+		sort.Slice(req.QueryString, func(i, j int) bool { return req.QueryString[i].Name < req.QueryString[j].Name })
+	}
+	if r.HasPostData {
+		req.PostData = &harPostData{Text: r.PostData}
+		for _, h := range req.Headers {
+			if strings.EqualFold(h.Name, "Content-Type") {
+				req.PostData.MimeType = h.Value
+			}
+		}
+	}
+	return req
+}
+
+func harResponseOf(r *proto.NetworkResponse) harResponse {
+	res := harResponse{
+		Status:      r.Status,
+		StatusText:  r.StatusText,
+		HTTPVersion: r.Protocol,
+		Cookies:     harCookies(r.Headers, "Set-Cookie"),
+		Headers:     harHeaders(r.Headers),
+		Content:     harContent{MimeType: r.MIMEType},
+		HeadersSize: -1,
+	}
+	if res.HTTPVersion == "" {
+		res.HTTPVersion = "HTTP/1.1"
+	}
+	for _, h := range res.Headers {
+		if strings.EqualFold(h.Name, "Location") {
+			res.RedirectURL = h.Value
+		}
+	}
+	return res
+}
+
+func harHeaders(headers proto.NetworkHeaders) []harNV {
+	list := []harNV{}
+	for name, value := range headers {
+		list = append(list, harNV{name, value.String()})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// harCookies are the cookies of the Cookie or Set-Cookie header, the
+// browser joins several Set-Cookie headers with newlines.
+func harCookies(headers proto.NetworkHeaders, name string) []harNV {
+	list := []harNV{}
+	for key, value := range headers {
+		if !strings.EqualFold(key, name) {
+			continue
+		}
+		h := http.Header{}
+		for _, line := range strings.Split(value.String(), "\n") {
+			h.Add(name, line)
+		}
+		var cookies []*http.Cookie
+		if name == "Cookie" {
+			cookies = (&http.Request{Header: h}).Cookies()
+		} else {
+			cookies = (&http.Response{Header: h}).Cookies()
+		}
+		for _, c := range cookies {
+			list = append(list, harNV{c.Name, c.Value})
+		}
+	}
+	return list
+}
+
+// harTimingsOf splits the resource timing into the HAR phases, -1 marks the
+// phases that didn't happen. The times of t are milliseconds since its
+// RequestTime.
+func harTimingsOf(t *proto.NetworkResourceTiming, finished *proto.MonotonicTime) harTimings {
+	if t == nil {
+		return harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	}
+	span := func(start, end float64) float64 {
+		if start < 0 || end < 0 {
+			return -1
+		}
+		return end - start
+	}
+	timings := harTimings{
+		Blocked: -1,
+		DNS:     span(t.DNSStart, t.DNSEnd),
+		Connect: span(t.ConnectStart, t.ConnectEnd),
+		SSL:     span(t.SslStart, t.SslEnd),
+		// send, wait and receive can't be -1 in a har
+		Send: math.Max(span(t.SendStart, t.SendEnd), 0),
+		Wait: math.Max(span(t.SendEnd, t.ReceiveHeadersEnd), 0),
+	}
+	if finished != nil {
+		end := float64(finished.Duration)/float64(time.Millisecond) - t.RequestTime*1000
+		if receive := end - t.ReceiveHeadersEnd; receive > 0 {
+			timings.Receive = receive
+		}
+	}
+	return timings
+}
+
+// total is the entry time, the sum of the phases that happened. The ssl time
+// is part of connect already.
+func (t harTimings) total() float64 {
+	sum := 0.0
+	for _, d := range []float64{t.Blocked, t.DNS, t.Connect, t.Send, t.Wait, t.Receive} {
+		if d > 0 {
+			sum += d
+		}
+	}
+	return sum
+}
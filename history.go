@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// historyCmd prints the session history of a page, the current entry marked
+// with a *, or moves back or forward in it like the browser buttons do.
+//
+//	ctfhelper history <target>
+//	ctfhelper history back|forward <target>
+func historyCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("history")
+	addTargetFlags(fs)
+	_ = fs.Parse(args)
+	action, rest := "", fs.Args()
+	if fs.Arg(0) == "back" || fs.Arg(0) == "forward" {
+		action, rest = fs.Arg(0), fs.Args()[1:]
+	}
+	parseArgs(fs, rest)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+	query := fs.Arg(0)
+
+	p, err := resolveTarget(b, query)
+	if err != nil {
+		return err
+	}
+	history, err := proto.PageGetNavigationHistory{}.Call(p)
+	if err != nil {
+		return err
+	}
+
+	if action == "" {
+		for i, e := range history.Entries {
+			mark := " "
+			if i == history.CurrentIndex {
+				mark = "*"
+			}
+			fmt.Printf("%s %-3d %s %q\n", mark, i, e.URL, e.Title)
+		}
+		return nil
+	}
+
+	to := history.CurrentIndex - 1
+	if action == "forward" {
+		to = history.CurrentIndex + 1
+	}
+	if to < 0 || to >= len(history.Entries) {
+		return fmt.Errorf("can't go %s from entry %d of %d", action, history.CurrentIndex, len(history.Entries))
+	}
+	entry := history.Entries[to]
+	if err := (proto.PageNavigateToHistoryEntry{EntryID: entry.ID}).Call(p); err != nil {
+		return err
+	}
+	fmt.Printf("* %-3d %s %q\n", to, entry.URL, entry.Title)
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// newPageRequest builds a request that carries p's cookies for rawURL and
+// its user agent, for when a request can't be sent by the page itself.
+func newPageRequest(p *rod.Page, method, rawURL string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	ua, err := p.Eval("navigator.userAgent")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", ua.Value.String())
+
+	cookies, err := p.Cookies([]string{rawURL})
+	if err != nil {
+		return nil, err
+	}
+	pairs := []string{}
+	for _, c := range cookies {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	if len(pairs) > 0 {
+		req.Header.Set("Cookie", strings.Join(pairs, "; "))
+	}
+
+	return req, nil
+}
+
+// noRedirectClient returns 30x responses instead of following them.
+var noRedirectClient = &http.Client{
+	CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+}
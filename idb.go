@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// idbPageSize is how many records of an object store are read per request.
+const idbPageSize = 100
+
+// idbDatabase is a database of idb's output.
+type idbDatabase struct {
+	Name    string     `json:"name"`
+	Version float64    `json:"version"`
+	Stores  []idbStore `json:"stores"`
+}
+
+type idbStore struct {
+	Name          string                  `json:"name"`
+	KeyPath       *proto.IndexedDBKeyPath `json:"keyPath"`
+	AutoIncrement bool                    `json:"autoIncrement"`
+	Records       []idbRecord             `json:"records"`
+}
+
+type idbRecord struct {
+	Key        interface{} `json:"key"`
+	PrimaryKey interface{} `json:"primaryKey"`
+	Value      interface{} `json:"value"`
+}
+
+// idbCmd prints the IndexedDB databases of a page's origin as json, with
+// their object stores and all records, read through the devtools IndexedDB
+// domain, so it works without knowing how the app opens them.
+//
+//	ctfhelper idb [-db name] [-store name] <target> [file]
+func idbCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("idb")
+	db := fs.String("db", "", "only dump the database of this `name`")
+	store := fs.String("store", "", "only dump the object store of this `name`")
+	parseArgs(fs, args)
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval("() => location.origin")
+	if err != nil {
+		return err
+	}
+	origin := res.Value.String()
+	if err := (proto.IndexedDBEnable{}).Call(p); err != nil {
+		return err
+	}
+
+	names, err := proto.IndexedDBRequestDatabaseNames{SecurityOrigin: origin}.Call(p)
+	if err != nil {
+		return err
+	}
+	dbNames := names.DatabaseNames
+	if *db != "" {
+		if !containsString(dbNames, *db) {
+			return fmt.Errorf("%s has no database %q, only: %s", origin, *db, strings.Join(dbNames, ", "))
+		}
+		dbNames = []string{*db}
+	}
+
+	dump := []idbDatabase{}
+	for _, name := range dbNames {
+		d, err := dumpDatabase(p, origin, name, *store)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		dump = append(dump, d)
+	}
+
+	if fs.NArg() == 2 {
+		return utils.OutputFile(outputPath(fs.Arg(1)), dump)
+	}
+	fmt.Println(utils.MustToJSON(dump))
+	return nil
+}
+
+// dumpDatabase reads the object stores of a database, or only the one named
+// store if it isn't empty.
+func dumpDatabase(p *rod.Page, origin, name, store string) (idbDatabase, error) {
+	res, err := proto.IndexedDBRequestDatabase{SecurityOrigin: origin, DatabaseName: name}.Call(p)
+	if err != nil {
+		return idbDatabase{}, err
+	}
+	db := res.DatabaseWithObjectStores
+	d := idbDatabase{Name: db.Name, Version: db.Version, Stores: []idbStore{}}
+
+	found := false
+	for _, s := range db.ObjectStores {
+		if store != "" && s.Name != store {
+			continue
+		}
+		found = true
+		records, err := dumpObjectStore(p, origin, name, s.Name)
+		if err != nil {
+			return d, fmt.Errorf("%s: %w", s.Name, err)
+		}
+		d.Stores = append(d.Stores, idbStore{Name: s.Name, KeyPath: s.KeyPath, AutoIncrement: s.AutoIncrement, Records: records})
+	}
+	if store != "" && !found {
+		return d, fmt.Errorf("no object store %q", store)
+	}
+	return d, nil
+}
+
+// dumpObjectStore reads all records of an object store, a page at a time.
+func dumpObjectStore(p *rod.Page, origin, db, store string) ([]idbRecord, error) {
+	records := []idbRecord{}
+	for {
+		res, err := proto.IndexedDBRequestData{
+			SecurityOrigin:  origin,
+			DatabaseName:    db,
+			ObjectStoreName: store,
+			SkipCount:       len(records),
+			PageSize:        idbPageSize,
+		}.Call(p)
+		if err != nil {
+			return records, err
+		}
+		for _, e := range res.ObjectStoreDataEntries {
+			var r idbRecord
+			if r.Key, err = remoteValue(p, e.Key); err != nil {
+				return records, err
+			}
+			if r.PrimaryKey, err = remoteValue(p, e.PrimaryKey); err != nil {
+				return records, err
+			}
+			if r.Value, err = remoteValue(p, e.Value); err != nil {
+				return records, err
+			}
+			records = append(records, r)
+		}
+		if !res.HasMore || len(res.ObjectStoreDataEntries) == 0 {
+			return records, nil
+		}
+	}
+}
+
+// remoteValue is the json value of obj, fetched from the page when obj is
+// an object rather than a primitive.
+func remoteValue(p *rod.Page, obj *proto.RuntimeRemoteObject) (interface{}, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	if obj.ObjectID == "" {
+		return obj.Value.Val(), nil
+	}
+	res, err := proto.RuntimeCallFunctionOn{
+		ObjectID:            obj.ObjectID,
+		FunctionDeclaration: "function() { return this }",
+		ReturnByValue:       true,
+	}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+	return res.Result.Value.Val(), nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+
+	"github.com/go-rod/rod"
+)
+
+var injectFiles stringsFlag
+
+func init() {
+	flag.Var(&injectFiles, "inject", "also run this js `file` in every hooked page before its own scripts, repeatable")
+}
+
+// injectScripts registers the -inject files on p for every following
+// document. The files are read for each page, so edits apply to the next
+// hooked page without restarting the tool.
+func injectScripts(p *rod.Page) error {
+	for _, file := range injectFiles {
+		js, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := p.EvalOnNewDocument(string(js) + "\n//# sourceURL=" + file); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+var insecure = flag.Bool("insecure", false, "CTF targets only: accept invalid, self-signed or expired certificates, in the browser and in the tool's own requests")
+
+// insecureClient makes the tool's own requests accept any certificate.
+func insecureClient() {
+	clientTransport().TLSClientConfig.InsecureSkipVerify = true
+}
+
+// ignoreCertErrors makes p load pages with certificate errors.
+func ignoreCertErrors(p *rod.Page) error {
+	return proto.SecuritySetIgnoreCertificateErrors{Ignore: true}.Call(p)
+}
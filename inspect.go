@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// inspectJS collects what a page exposes, with the urls resolved against
+// document.baseURI.
+const inspectJS = `() => {
+	const abs = (u) => {
+		try { return new URL(u, document.baseURI).href } catch (e) { return u }
+	}
+	const uniq = (list) => [...new Set(list)]
+	return {
+		baseURI: document.baseURI,
+		links: uniq([...document.querySelectorAll("a[href], area[href]")].map(a => abs(a.getAttribute("href")))),
+		forms: [...document.forms].map(f => ({
+			action: abs(f.getAttribute("action") || ""),
+			method: (f.getAttribute("method") || "get").toLowerCase(),
+			inputs: [...f.elements].filter(el => el.name).map(el => ({
+				name: el.name,
+				type: el.type,
+				value: el.value,
+			})),
+		})),
+		scripts: uniq([...document.querySelectorAll("script[src]")].map(s => abs(s.getAttribute("src")))),
+	}
+}`
+
+// inspectCmd prints the links, forms and external scripts of a page as json,
+// a map of its attack surface.
+//
+//	ctfhelper inspect <target>
+func inspectCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("inspect")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval(inspectJS)
+	if err != nil {
+		return err
+	}
+	fmt.Println(res.Value.JSON("", "  "))
+	return nil
+}
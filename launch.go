@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	launch         = flag.Bool("launch", false, "start a chrome with remote debugging on the control url's port when none is listening")
+	headless       = flag.Bool("headless", false, "with -launch, start chrome without a window, e.g. on a box without display")
+	noSandbox      = flag.Bool("no-sandbox", false, "with -launch, start chrome with --no-sandbox, needed as root and in most containers")
+	connectTimeout = flag.Duration("connect-timeout", 10*time.Second, "how long to retry connecting to a browser that isn't ready yet")
+	userDataDir    = flag.String("user-data-dir", defaultUserDataDir(), "with -launch, the chrome profile `dir`, kept across launches so logins survive; empty for a throwaway one")
+)
+
+// defaultUserDataDir is the profile -launch reuses, in the user's cache
+// directory.
+func defaultUserDataDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "ctfhelper", "chrome")
+}
+
+// connectBrowser connects the client, retrying with backoff for the
+// -connect-timeout as a freshly started chrome takes a moment to listen.
+// With -launch a browser that isn't there is launched instead of waited for.
+func connectBrowser(c *ctfhelper.Client) error {
+	timeout := *connectTimeout
+	if *launch {
+		timeout = 0
+	}
+	err := connectRetry(c, timeout)
+	if err == nil || !*launch {
+		return err
+	}
+
+	logrus.WithField("url", c.ControlURL).WithError(err).Info("no browser listening, launching one")
+	c.ControlURL, err = launchBrowser(c.ControlURL)
+	if err != nil {
+		return err
+	}
+	return connectRetry(c, *connectTimeout)
+}
+
+// connectRetry tries to connect until it succeeds or timeout elapsed, and
+// returns the last connection error.
+func connectRetry(c *ctfhelper.Client, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var last error
+	err := utils.Retry(ctx, utils.BackoffSleeper(200*time.Millisecond, 3*time.Second, nil), func() (bool, error) {
+		last = c.Connect()
+		if last != nil {
+			logrus.WithError(last).Debug("connect")
+		}
+		return last == nil, nil
+	})
+	if last != nil {
+		return last
+	}
+	return err
+}
+
+// checkLaunchFlags warns about the launch options given without -launch.
+func checkLaunchFlags() {
+	if *launch {
+		return
+	}
+	if *headless {
+		logrus.Warn("-headless is ignored without -launch")
+	}
+	if *noSandbox {
+		logrus.Warn("-no-sandbox is ignored without -launch")
+	}
+	if *userDataDir != defaultUserDataDir() {
+		logrus.Warn("-user-data-dir is ignored without -launch")
+	}
+}
+
+// launchBrowser starts a chrome debuggable on the port of controlURL, with a
+// window unless -headless, and returns its websocket url. The browser isn't
+// tied to the tool, it keeps running after ctfhelper exits and the next
+// invocations connect to it, with the -user-data-dir profile.
+func launchBrowser(controlURL string) (string, error) {
+	port, err := controlPort(controlURL)
+	if err != nil {
+		return "", err
+	}
+
+	l := launcher.New().
+		Headless(*headless).
+		Leakless(false).
+		RemoteDebuggingPort(port)
+	if *noSandbox {
+		l.Set("no-sandbox")
+	}
+	if *userDataDir != "" {
+		l.UserDataDir(*userDataDir)
+	}
+	proxyLaunchFlags(l)
+	u, err := l.Launch()
+	if err != nil {
+		return "", err
+	}
+
+	dir, _ := l.Get("user-data-dir")
+	logrus.WithFields(logrus.Fields{"url": u, "port": port, "user-data-dir": dir}).
+		Info("launched chrome, it keeps running for the next invocations")
+	return u, nil
+}
+
+// controlPort is the port of a control url such as :9222, host:9222 or
+// http://host:9222.
+func controlPort(controlURL string) (int, error) {
+	host := controlURL
+	if strings.Contains(controlURL, "://") {
+		u, err := url.Parse(controlURL)
+		if err != nil {
+			return 0, err
+		}
+		host = u.Host
+	}
+	_, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(port)
+}
+
+// checkControlURL rejects control urls that can't name a debugging
+// endpoint, before any connection attempt hides the typo behind a timeout.
+func checkControlURL(controlURL string) error {
+	if strings.Contains(controlURL, "://") {
+		u, err := url.Parse(controlURL)
+		if err != nil {
+			return err
+		}
+		switch u.Scheme {
+		case "http", "https", "ws", "wss":
+		default:
+			return fmt.Errorf("control url %q: want http, https, ws or wss, not %s", controlURL, u.Scheme)
+		}
+		if u.Hostname() == "" {
+			return fmt.Errorf("control url %q has no host", controlURL)
+		}
+		if u.Port() == "" {
+			// the scheme's default port
+			return nil
+		}
+	}
+	port, err := controlPort(controlURL)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("control url %q: want :port, host:port or a url, e.g. :9222 or http://host:9222", controlURL)
+	}
+	return nil
+}
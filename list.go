@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// listEntry is a target of list -json. Index is the page's index for
+// resolveTarget, unset for other targets, and Browser is set with several
+// -control-url.
+type listEntry struct {
+	Index    *int                       `json:"index,omitempty"`
+	TargetID proto.TargetTargetID       `json:"targetID"`
+	Type     proto.TargetTargetInfoType `json:"type"`
+	URL      string                     `json:"url"`
+	Title    string                     `json:"title"`
+	Attached bool                       `json:"attached"`
+	Opener   proto.TargetTargetID       `json:"opener,omitempty"`
+	Browser  string                     `json:"browser,omitempty"`
+}
+
+// listCmd prints the open pages and injects the log() hook into each of them.
+// With several -control-url the pages are grouped by browser and indexed as
+// b<N>/p<M>, which resolveTarget accepts. With -json it prints a line per
+// target instead, iframes and workers included, for jq or fzf.
+//
+//	ctfhelper list [-json]
+func listCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("list")
+	asJSON := fs.Bool("json", false, "print a json object per target: index, targetID, type, url, title, attached and opener")
+	parseArgs(fs, args)
+	if fs.NArg() != 0 {
+		return usageError(fs)
+	}
+
+	for n, br := range listedBrowsers(b) {
+		pages, err := br.Pages()
+		if err != nil {
+			return err
+		}
+
+		logrus.WithField("count", len(pages)).Debug("pages found")
+		index := map[proto.TargetTargetID]int{}
+		for i, p := range pages {
+			index[p.TargetID] = i
+			if err := injectHooks(p); err != nil {
+				logrus.WithField("TargetID", p.TargetID).WithError(err).Error("injectHooks")
+			}
+			if *asJSON {
+				continue
+			}
+			href, err := p.Eval("()=>document.location.href")
+			if err != nil {
+				logrus.WithField("TargetID", p.TargetID).WithError(err).Error("page is not responding")
+				continue
+			}
+			// fmt.Printf("%d\t %s %s %s\n", i, p.TargetID, p.MustEval("()=>document.location.href"), p.MustEval("()=>document.title"))
+			if len(browsers) > 1 {
+				fmt.Printf("b%d/p%-4d %s %s\n", n, i, p.TargetID, href.Value.String())
+			} else {
+				fmt.Printf("%-04d %s %s\n", i, p.TargetID, href.Value.String())
+			}
+		}
+		if *asJSON {
+			if err := printTargets(br, n, index); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// printTargets prints the targets of the n-th browser as list -json does,
+// index holding the page indexes.
+func printTargets(br *rod.Browser, n int, index map[proto.TargetTargetID]int) error {
+	res, err := proto.TargetGetTargets{}.Call(br)
+	if err != nil {
+		return err
+	}
+	for _, t := range res.TargetInfos {
+		if t.Type == proto.TargetTargetInfoTypeBrowser {
+			continue
+		}
+		entry := listEntry{
+			TargetID: t.TargetID,
+			Type:     t.Type,
+			URL:      t.URL,
+			Title:    t.Title,
+			Attached: t.Attached,
+			Opener:   t.OpenerID,
+		}
+		if i, ok := index[t.TargetID]; ok {
+			entry.Index = &i
+		}
+		if len(browsers) > 1 {
+			entry.Browser = fmt.Sprintf("b%d", n)
+		}
+		printJSONLine(entry)
+	}
+	return nil
+}
+
+// listedBrowsers are the browsers list shows, with the deadline of b.
+func listedBrowsers(b *rod.Browser) []*rod.Browser {
+	if len(browsers) <= 1 {
+		return []*rod.Browser{b}
+	}
+	list := make([]*rod.Browser, 0, len(browsers))
+	for _, br := range browsers {
+		list = append(list, br.Context(b.GetContext()))
+	}
+	return list
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"hash/fnv"
+	"net/url"
+	"strings"
+
+	"github.com/gookit/color"
+)
+
+var (
+	colorHosts = flag.Bool("color", false, "print the log() messages of each host in its own color")
+	decodeLog  = flag.String("decode", "", "decode the log() messages as base64, hex or url before printing them, when they are")
+)
+
+// hostColors are the colors -color picks from, red stays reserved for flags.
+var hostColors = []color.Color{
+	color.Cyan, color.Green, color.Yellow, color.Magenta, color.Blue,
+	color.LightCyan, color.LightGreen, color.LightYellow, color.LightMagenta, color.LightBlue,
+}
+
+// printLog prints a log() message prefixed with the host of the page that
+// sent it. Hooks older than the host parameter send none.
+func printLog(host, msg string) {
+	msg = decodeMessage(*decodeLog, msg)
+	reportFlags("log", msg)
+	c := color.Cyan
+	if *colorHosts {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(host))
+		c = hostColors[h.Sum32()%uint32(len(hostColors))]
+	}
+	if host == "" {
+		printColored(c, "%s\n", msg)
+		return
+	}
+	printColored(c, "[%s] %s\n", host, msg)
+}
+
+// decodeMessage decodes msg with the -decode encoding, keeping msg as it is
+// when it isn't encoded that way.
+func decodeMessage(encoding, msg string) string {
+	switch encoding {
+	case "base64":
+		// any of the standard and url-safe alphabets, padded or not
+		if decoded, ok := decodeBase64(strings.TrimSpace(msg)); ok {
+			return string(decoded)
+		}
+	case "hex":
+		if decoded, err := hex.DecodeString(strings.TrimSpace(msg)); err == nil {
+			return string(decoded)
+		}
+	case "url":
+		if decoded, err := url.QueryUnescape(msg); err == nil {
+			return decoded
+		}
+	}
+	return msg
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
 	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-rod/rod"
-	"github.com/go-rod/rod/lib/launcher"
-	"github.com/go-rod/rod/lib/proto"
-	"github.com/gookit/color"
+	"github.com/morentharia/ctfhelper/ctfhelper"
 	"github.com/sirupsen/logrus"
 )
 
@@ -16,50 +20,503 @@ const (
 	ChromeURL = ":9222"
 )
 
-func main() {
-	b := rod.New().ControlURL(launcher.MustResolveURL(ChromeURL))
-	err := b.Connect()
-	if err != nil {
-		log.Fatal(err)
+var controlURLFlags stringsFlag
+
+func init() {
+	flag.Var(&controlURLFlags, "control-url", "the browser's debugging address, or its ws:// url, "+
+		"defaults to $CTFHELPER_CONTROL_URL, then $CTFHELPER_CHROME_URL, then "+ChromeURL+"; repeatable, the pages of the second browser are b1/<target>, and so on")
+}
+
+// controlURLs are the addresses of the browsers to connect to: the
+// -control-url flags, else $CTFHELPER_CONTROL_URL, else the older
+// $CTFHELPER_CHROME_URL, else ChromeURL.
+func controlURLs() []string {
+	if len(controlURLFlags) > 0 {
+		return controlURLFlags
+	}
+	for _, env := range []string{"CTFHELPER_CONTROL_URL", "CTFHELPER_CHROME_URL"} {
+		if u := strings.TrimSpace(os.Getenv(env)); u != "" {
+			return []string{u}
+		}
+	}
+	return []string{ChromeURL}
+}
+
+// controlURL is the address of the first browser.
+func controlURL() string {
+	return controlURLs()[0]
+}
+
+// command is a named subcommand, invoked as `ctfhelper <name> args...`.
+type command struct {
+	usage string
+
+	// stay keeps the tool running after the command so the log() messages
+	// of the hooked pages keep being printed.
+	stay bool
+
+	run func(b *rod.Browser, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"assert":         {"assert -selector css -contains text | -eval expr -expect value <target>", false, assertCmd},
+		"autoclick":      {"autoclick [-skip regexp] [-settle 1s] <target>", false, autoclickCmd},
+		"click":          {"click [-index 0] [-settle 2s] <target> <css-selector>", false, clickCmd},
+		"cookie":         {"cookie [-domain d] [-path /] [-http-only] [-secure] <target> <name> <value>", false, cookieCmd},
+		"cookies":        {"cookies [dump] [-format json|netscape] <target> [file] | cookies load <target> <file> | cookies set [-from-file jar] [-set cookie]... <target>", false, cookiesCmd},
+		"cors":           {"cors <target> <url>", false, corsCmd},
+		"data-attrs":     {"data-attrs [-json] <target>", false, dataAttrsCmd},
+		"decode-scan":    {"decode-scan [-min 16] <target>", false, decodeScanCmd},
+		"diff-html":      {"diff-html [-wait-selector css] [-timeout 30s] <target>", false, diffHTMLCmd},
+		"dirbust":        {"dirbust [-w wordlist] [-c 10] [-hide 404] <target> <base-url>", false, dirbustCmd},
+		"domhash":        {"domhash [-normalize regexp] [-watch 5s] <target>", false, domhashCmd},
+		"downloads":      {"downloads -dir <dir> <target>", false, downloadsCmd},
+		"dump":           {"dump [-selector css [-all]] [-out file] <target>", true, dumpCmd},
+		"dump-all":       {"dump-all [-c 5] -out-dir <dir>", false, dumpAllCmd},
+		"eval-file":      {"eval-file [-new-document] <target> <script.js>", false, evalFileCmd},
+		"export-test":    {"export-test [-session file] [-name Solve] <out_test.go>", false, exportTestCmd},
+		"form":           {"form [-field name=value]... [-submit] [-timeout 5s] <target>", false, formCmd},
+		"har":            {"har [-duration 10s] <target> <out.har>", false, harCmd},
+		"history":        {"history [back|forward] <target>", false, historyCmd},
+		"idb":            {"idb [-db name] [-store name] <target> [file]", false, idbCmd},
+		"inspect":        {"inspect <target>", false, inspectCmd},
+		"list":           {"list [-json]", true, listCmd},
+		"navigate":       {"navigate [-wait-selector css] [-timeout 10s] [-user-agent ua | -ua-preset mobile] [-header 'Name: Value']... [-basic-auth user:pass] [-device iphone-x] [-geo lat,lng] [-timings] [-har out.har] <target> <url>", true, navigateCmd},
+		"patch":          {"patch -url-pattern glob [-regex] -replace old -new new... <target>", false, patchCmd},
+		"pdf":            {"pdf [-landscape] [-print-background] [-scale 1] <target> <out.pdf>", false, pdfCmd},
+		"poll":           {"poll -selector css [-interval 5s] [-max 60] <target>", false, pollCmd},
+		"redirects":      {"redirects [-redirect-bodies] [-out dir] <target> <url>", false, redirectsCmd},
+		"reflect":        {"reflect <target> <url-template>", false, reflectCmd},
+		"repl":           {"repl <target>", false, replCmd},
+		"replay-session": {"replay-session [-target id] [-extract name=regexp]... <file>", false, replaySessionCmd},
+		"screenshot":     {"screenshot [-full] [-selector css] [-o out.png] <target> [out.png]", false, screenshotCmd},
+		"scroll":         {"scroll [-to-bottom | -by N] [-settle 500ms] [-max-scrolls 50] [-dump] <target>", false, scrollCmd},
+		"serve":          {"serve [-listen 127.0.0.1:8080] [-token token]", false, serveCmd},
+		"sri":            {"sri <target>", false, sriCmd},
+		"ssti":           {"ssti <target> <url-template>", false, sstiCmd},
+		"state":          {"state <target>", false, stateCmd},
+		"storage":        {"storage dump [-frames] <target> [file] | storage set [-session] <target> <key> <value>", false, storageCmd},
+		"tabs-export":    {"tabs-export [-cookies] <file>", false, tabsExportCmd},
+		"tabs-open":      {"tabs-open <file>", false, tabsOpenCmd},
+		"type":           {"type [-selector css] <target> <text>", false, typeCmd},
+		"ws":             {"ws [-filter regexp] <target>", false, wsCmd},
+	}
+}
+
+// aliases are shorter names of commands.
+var aliases = map[string]string{
+	"nav": "navigate",
+}
+
+// lookupCommand returns the command args ask for and its arguments: list
+// without args, a command name or alias, else the positional dump and
+// navigate forms from before there were subcommands.
+func lookupCommand(args []string) (name string, cmdArgs []string, positional, ok bool) {
+	if len(args) == 0 {
+		return "list", nil, false, true
+	}
+	name = args[0]
+	if real, ok := aliases[name]; ok {
+		name = real
+	}
+	if _, ok := commands[name]; ok {
+		return name, args[1:], false, true
+	}
+	switch len(args) {
+	case 1:
+		return "dump", args, true, true
+	case 2:
+		return "navigate", args, true, true
+	}
+	return "", nil, false, false
+}
+
+// help prints the usage of the command args name, or of the tool, and
+// returns the exit code.
+//
+//	ctfhelper help [command]
+func help(args []string) int {
+	if len(args) == 0 {
+		flag.CommandLine.SetOutput(os.Stdout)
+		flag.Usage()
+		return 0
+	}
+	name := args[0]
+	if real, ok := aliases[name]; ok {
+		name = real
+	}
+	if _, ok := commands[name]; !ok {
+		fmt.Fprintf(os.Stderr, "no command %q\n", args[0])
+		return exitUsage
 	}
-	js := `window.log = function log(msg){fetch("/challengehelperlog?msg="+msg)}`
+	// the flags are declared by the command itself, whose -h prints them
+	// and exits before the browser is used
+	_ = commands[name].run(nil, []string{"-h"})
+	return 0
+}
+
+// errUsage is returned by commands called with bad arguments, after they
+// printed their usage.
+var errUsage = errors.New("bad usage")
 
-	go b.HijackRequests().MustAdd("*/challengehelperlog*", func(h *rod.Hijack) {
+// The exit codes of ctfhelper, besides 0 for success.
+const (
+	exitFailure  = 1 // the command failed
+	exitUsage    = 2 // bad arguments
+	exitConnect  = 3 // the browser can't be reached
+	exitNoTarget = 4 // no single page matches <target>
+	exitEval     = 5 // the page's js threw
+)
+
+// exitCode is the exit code for the error a command returned.
+func exitCode(err error) int {
+	var evalErr *rod.ErrEval
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, ctfhelper.ErrNoPage):
+		return exitNoTarget
+	case errors.As(err, &evalErr):
+		return exitEval
+	}
+	return exitFailure
+}
+
+// newFlagSet returns the flag set of a command, its -h prints the command's
+// usage line before the flags.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: ctfhelper %s\n", commands[name].usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func usageError(fs *flag.FlagSet) error {
+	fs.Usage()
+	return errUsage
+}
+
+// stringsFlag is a repeatable string flag.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "usage: ctfhelper [options] [command] [args]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %s\n", commands[name].usage)
+	}
+	aliasNames := make([]string, 0, len(aliases))
+	for alias := range aliases {
+		aliasNames = append(aliasNames, alias+" = "+aliases[alias])
+	}
+	sort.Strings(aliasNames)
+	fmt.Fprintf(out, "\naliases: %s\n", strings.Join(aliasNames, ", "))
+	fmt.Fprintf(out, "\n`ctfhelper help <command>` prints the options of a command. Without a\n"+
+		"command the pages are listed, and for compatibility `ctfhelper <targetID>`\n"+
+		"dumps and `ctfhelper <targetID> <url>` navigates.\n\noptions:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(out, "\nexit codes: 1 the command failed, 2 bad usage, 3 can't connect to the\n"+
+		"browser, 4 no single page matches the target, 5 the page's js threw\n")
+}
+
+var opTimeout = flag.Duration("timeout", 0, "abort the command's page operations after this long, e.g. on a page stuck in a loop; 0 waits forever")
+
+// browsers are the connected browsers, in -control-url order. Commands get
+// the first one, the others are reached through the b<N>/ prefix of
+// resolveTarget.
+var browsers []*rod.Browser
+
+// hijackLogs starts serving the log() requests of b's pages, printed and
+// forwarded to the sink and tap when not nil, and the hijack rules, until
+// shutdown.
+func hijackLogs(b *rod.Browser, sink *syslogWriter, tap *tapWriter, rules *ruleSet) error {
+	r := b.HijackRequests()
+	// the log() requests being handled, waited for on shutdown so none of
+	// them is lost
+	var pending sync.WaitGroup
+	err := r.Add(ctfhelper.LogPattern, "", func(h *rod.Hijack) {
+		pending.Add(1)
+		defer pending.Done()
+		logrus.WithField("url", h.Request.URL().String()).Debug("hijacked")
+		msg := ctfhelper.LogMessage(h)
+		if strings.HasPrefix(msg, trafficPrefix) {
+			handleTraffic(msg)
+			h.Response.SetBody("")
+			return
+		}
 		// fmt.Printf("%s\n", h.Request.URL().Query().Get("msg"))
-		color.Cyan.Printf("%s\n", h.Request.URL().Query().Get("msg"))
+		printLog(h.Request.URL().Query().Get("host"), msg)
+		if tap != nil {
+			tap.Write(h.Request.URL().Query().Get("host"), msg)
+		}
+		if sink != nil {
+			if err := sink.Send(h.Request.URL().Query().Get("target"), msg); err != nil {
+				logrus.WithError(err).Error("syslog")
+			}
+		}
 
 		h.Response.SetBody("")
-	}).Run()
+	})
+	if err != nil {
+		return err
+	}
+	if rules != nil {
+		if err := addRules(r, rules); err != nil {
+			return err
+		}
+	}
+	go r.Run()
+	onShutdown(func() {
+		if err := r.Stop(); err != nil {
+			logrus.WithError(err).Debug("router.Stop")
+		}
+		if !waitTimeout(&pending, 2*time.Second) {
+			logrus.Warn("gave up waiting for the log() messages being handled")
+		}
+	})
+	return nil
+}
 
-	switch {
-	case len(os.Args) == 2:
-		targetID := os.Args[1]
-		p, err := b.PageFromTarget(proto.TargetTargetID(targetID))
+// clientOf wraps a connected browser for the helpers of the ctfhelper package.
+func clientOf(b *rod.Browser) *ctfhelper.Client {
+	return &ctfhelper.Client{Browser: b}
+}
+
+// hooked holds the targets injectHooks already ran on, so a page reached
+// through several paths doesn't get the hooks twice.
+var hooked sync.Map
+
+// injectHooks registers the page-side helpers on p for every following document.
+func injectHooks(p *rod.Page) error {
+	if _, done := hooked.LoadOrStore(p.TargetID, true); done {
+		return nil
+	}
+	// InjectLogger only needs the page
+	if err := (&ctfhelper.Client{}).InjectLogger(p); err != nil {
+		return err
+	}
+	if *hookTraffic {
+		if _, err := p.EvalOnNewDocument(trafficHook(p.TargetID)); err != nil {
+			return err
+		}
+	}
+	return injectScripts(p)
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+	args := flag.Args()
+	if err := loadConfig(); err != nil {
+		logrus.WithError(err).Fatal("config")
+	}
+	if len(args) > 0 && args[0] == "help" {
+		os.Exit(help(args[1:]))
+	}
+	// pick the command before connecting, bad usage needs no browser
+	name, cmdArgs, positional, ok := lookupCommand(args)
+	if !ok {
+		flag.Usage()
+		os.Exit(exitUsage)
+	}
+	cmd := commands[name]
+	if err := setLogLevel(); err != nil {
+		logrus.WithError(err).Fatal("-log-level")
+	}
+	switch *decodeLog {
+	case "", "base64", "hex", "url":
+	default:
+		logrus.WithField("decode", *decodeLog).Fatal("-decode must be base64, hex or url")
+	}
+	checkLaunchFlags()
+	if *pipeMode {
+		textOut = os.Stderr
+	}
+	if *insecure {
+		insecureClient()
+	}
+	if err := setupProxy(); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := checkBrowserName(); err != nil {
+		logrus.Fatal(err)
+	}
+	rules, err := startupRules()
+	if err != nil {
+		logrus.WithError(err).Fatal("rules")
+	}
+	if len(rules) > 0 && !canHijack() {
+		logrus.Fatal("-rules, -block and -mock need request hijacking, which " + *browserName + " doesn't support")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	handleSignals(cancel)
+	for _, u := range controlURLs() {
+		if err := checkControlURL(u); err != nil {
+			logrus.Error(err)
+			os.Exit(exitConnect)
+		}
+	}
+	for _, u := range controlURLs() {
+		client := ctfhelper.New(u)
+		client.Browser = client.Browser.Context(ctx)
+		if *quiet {
+			setQuiet(client.Browser)
+		}
+		err = connectBrowser(client)
 		if err != nil {
-			logrus.WithField("TargetID", targetID).WithError(err).Error("b.PageFromTarget")
-			return
+			logrus.WithField("url", client.ControlURL).WithError(err).
+				Error("can't connect to the browser; start chrome with --remote-debugging-port or use -launch")
+			os.Exit(exitConnect)
 		}
-		fmt.Printf("%s\n", p.MustEval("document.documentElement.innerHTML").String())
-	case len(os.Args) == 3:
-		targetID := os.Args[1]
-		newLoaction := os.Args[2]
-		p, err := b.PageFromTarget(proto.TargetTargetID(targetID))
+		logrus.WithField("url", client.DebuggerURL).Debug("resolved the control url")
+		browsers = append(browsers, client.Browser)
+	}
+	b := browsers[0]
+
+	var sink *syslogWriter
+	if *logSyslog != "" {
+		sink, err = dialSyslog(*logSyslog, *syslogTag)
 		if err != nil {
-			logrus.WithError(err).Error("b.PageFromTarget")
-			return
+			logrus.WithError(err).Fatal("dialSyslog")
 		}
-		p.MustEvalOnNewDocument(js)
-		// TODO eval window.location
-		p.Navigate(newLoaction)
-		p.WaitLoad()
-		fmt.Printf("%s\n", p.MustEval("document.documentElement.innerHTML").String())
-	default:
-		for i, p := range b.MustPages() {
-			p.MustEvalOnNewDocument(js)
-			// fmt.Printf("%d\t %s %s %s\n", i, p.TargetID, p.MustEval("()=>document.location.href"), p.MustEval("()=>document.title"))
-			fmt.Printf("%-04d %s %s\n", i, p.TargetID, p.MustEval("()=>document.location.href"))
+		onShutdown(sink.Close)
+	}
+
+	var tap *tapWriter
+	if *tapFile != "" {
+		if tap, err = openTap(*tapFile); err != nil {
+			logrus.WithError(err).Fatal("-tap")
+		}
+	}
+
+	if canHijack() {
+		// the rules are only hijacked for when there are some, or may be
+		// after a reload
+		var set *ruleSet
+		if len(rules) > 0 || *rulesFile != "" {
+			set = newRuleSet(rules)
+		}
+		for _, br := range browsers {
+			if err := hijackLogs(br, sink, tap, set); err != nil {
+				logrus.WithError(err).Fatal("rules")
+			}
+		}
+		if *rulesFile != "" {
+			go watchRules(set)
+		}
+	} else {
+		logrus.Warn("request hijacking is not supported by " + *browserName + ", log() messages won't be shown")
+	}
+
+	if *ignoreAllCerts {
+		for _, br := range browsers {
+			if err := ignoreAllCertErrors(br); err != nil {
+				logrus.WithError(err).Error("ignoreAllCertErrors")
+			}
+		}
+	}
+
+	if !*noAutoHook || *followPopups {
+		for _, br := range browsers {
+			autoHook(br)
+		}
+	}
+
+	if *dumpBodiesDir != "" {
+		if err := dumpBodies(*dumpBodiesDir); err != nil {
+			logrus.WithError(err).Error("dumpBodies")
+		}
+	}
+
+	if *logRequests {
+		if err := startRequestLog(); err != nil {
+			logrus.WithError(err).Error("startRequestLog")
+		}
+	}
+
+	if *logConsole {
+		if err := startConsole(); err != nil {
+			logrus.WithError(err).Error("startConsole")
 		}
 	}
 
+	if *networkJSONL {
+		if err := streamNetwork(); err != nil {
+			logrus.WithError(err).Error("streamNetwork")
+		}
+	}
+
+	if *logWS || *wsFile != "" {
+		if err := startWSLog(); err != nil {
+			logrus.WithError(err).Fatal("-log-ws")
+		}
+	}
+
+	if *captureFile != "" {
+		if err := startCapture(*captureFile); err != nil {
+			logrus.WithError(err).Fatal("-capture")
+		}
+	}
+
+	if *pipeMode {
+		if err := runPipe(b); err != nil {
+			logrus.WithError(err).Error("pipe")
+			exit(exitFailure)
+		}
+		exit(0)
+	}
+
+	// the command's browser operations share the -timeout deadline, the
+	// connection and the log() hijack don't
+	cmdBrowser := b
+	if *opTimeout > 0 {
+		cmdCtx, cancel := context.WithTimeout(ctx, *opTimeout)
+		defer cancel()
+		cmdBrowser = b.Context(cmdCtx)
+	}
+	if err := cmd.run(cmdBrowser, cmdArgs); err != nil {
+		code := exitCode(err)
+		if code == exitUsage {
+			exit(code)
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("-timeout %s exceeded: %w", *opTimeout, err)
+		}
+		logrus.WithField("cmd", name).WithError(err).Error("command failed")
+		exit(code)
+	}
+	// only the calls that succeeded are worth replaying
+	if *recordFile != "" {
+		recorded := name
+		if positional {
+			recorded = ""
+		}
+		if err := recordCall(*recordFile, args, recorded); err != nil {
+			logrus.WithError(err).Error("recordCall")
+		}
+	}
+	// with -q the result is all that's wanted, e.g. in x=$(ctfhelper -q dump
+	// admin), so the log() messages aren't waited for
+	if !cmd.stay || *quiet {
+		exit(0)
+	}
+
 	select {}
 }
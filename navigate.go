@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/devices"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// navigateCmd opens url in a page, with the log() hook injected, and prints
+// the resulting html. With -wait-selector the html is only printed once an
+// element matching it was rendered by the page's scripts.
+//
+//	ctfhelper navigate [-wait-selector css] [-timeout 10s] [-user-agent ua | -ua-preset mobile]
+//	                   [-header 'Name: Value']... [-basic-auth user:pass]
+//	                   [-device iphone-x] [-geo lat,lng] [-timings] [-har out.har] <target> <url>
+func navigateCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("navigate")
+	waitSelector := fs.String("wait-selector", "", "after load, wait for an element matching this css selector")
+	timeout := fs.Duration("timeout", 10*time.Second, "how long to wait for -wait-selector")
+	userAgent := fs.String("user-agent", "", "user agent of this page, for this and the following navigations")
+	uaPreset := fs.String("ua-preset", "", "mobile, android, googlebot or desktop: a realistic user agent and its platform")
+	var headers stringsFlag
+	fs.Var(&headers, "header", "`Name: Value` header to add to the page's requests while the tool runs, repeatable")
+	basicAuth := fs.String("basic-auth", "", "`user:pass` sent as an Authorization: Basic header")
+	deviceFlag := fs.String("device", "", "emulate the viewport, touch and user agent of a device, e.g. iphone-x or pixel-2")
+	geoFlag := fs.String("geo", "", "`lat,lng` reported by the geolocation api")
+	timings := fs.Bool("timings", false, "print the dns, connect, ttfb and load durations of the navigation to stderr as json")
+	harOut := fs.String("har", "", "write the traffic of the navigation, redirects included, to a HAR `file`")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	newLoaction := fs.Arg(1)
+	ua, err := userAgentOverride(*userAgent, *uaPreset)
+	if err != nil {
+		return err
+	}
+
+	var device *devices.Device
+	if *deviceFlag != "" {
+		d, err := lookupDevice(*deviceFlag)
+		if err != nil {
+			return err
+		}
+		device = &d
+	}
+	var geo *proto.EmulationSetGeolocationOverride
+	if *geoFlag != "" {
+		if geo, err = parseGeo(*geoFlag); err != nil {
+			return err
+		}
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := injectHooks(p); err != nil {
+		return err
+	}
+	if *insecure {
+		if err := ignoreCertErrors(p); err != nil {
+			return err
+		}
+	}
+	if len(headers) > 0 || *basicAuth != "" {
+		if err := setHeaders(p, headers, *basicAuth); err != nil {
+			return err
+		}
+	}
+	if device != nil {
+		if err := p.Emulate(*device, false); err != nil {
+			return err
+		}
+	}
+	if ua != nil {
+		if err := p.SetUserAgent(ua); err != nil {
+			return err
+		}
+	} else if device != nil {
+		ua = device.UserAgent()
+	}
+	if geo != nil {
+		if err := emulateGeo(b, p, geo); err != nil {
+			return err
+		}
+	}
+	if *fakeTime != "" {
+		if err := applyFakeTime(p, *fakeTime); err != nil {
+			return err
+		}
+	}
+	var stopHAR func() harFile
+	if *harOut != "" {
+		stopHAR = recordHAR(p)
+	}
+	// TODO eval window.location
+	if err := p.Navigate(newLoaction); err != nil {
+		return err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return err
+	}
+	if *waitSelector != "" {
+		if err := waitElement(p, *waitSelector, *timeout); err != nil {
+			return err
+		}
+	}
+	if stopHAR != nil {
+		if err := utils.OutputFile(outputPath(*harOut), stopHAR()); err != nil {
+			return err
+		}
+	}
+	if *timings {
+		if err := printTimings(p); err != nil {
+			logrus.WithError(err).Error("printTimings")
+		}
+	}
+	if *fakeTime != "" {
+		checkFakeTime(p, *fakeTime)
+	}
+	if ua != nil || geo != nil {
+		checkEmulation(p, ua, geo)
+	}
+	if *dismissConsentFlag {
+		if _, err := dismissConsent(p); err != nil {
+			logrus.WithError(err).Error("dismissConsent")
+		}
+	}
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", html)
+	reportFlags("navigate", html)
+	return nil
+}
+
+// waitElement polls until the page has an element matching selector, or
+// fails once timeout elapsed.
+func waitElement(p *rod.Page, selector string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := utils.Retry(ctx, utils.BackoffSleeper(100*time.Millisecond, time.Second, nil), func() (bool, error) {
+		has, _, err := p.Has(selector)
+		return has || err != nil, err
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("no element matched %q within %s", selector, timeout)
+	}
+	return err
+}
+
+// setHeaders adds the -header and -basic-auth headers to every request of
+// the page. The devtools session carries them, so they apply to this page
+// only and go away when the tool disconnects.
+func setHeaders(p *rod.Page, headers []string, basicAuth string) error {
+	dict := []string{}
+	for _, h := range headers {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return fmt.Errorf("-header %q: want Name: Value", h)
+		}
+		dict = append(dict, strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	if basicAuth != "" {
+		dict = append(dict, "Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(basicAuth)))
+	}
+	_, err := p.SetExtraHeaders(dict)
+	return err
+}
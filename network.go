@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+var networkJSONL = flag.Bool("network-jsonl", false, "stream every request and response of every tab to stdout as json lines")
+
+// networkEvent is one line of -network-jsonl output.
+type networkEvent struct {
+	Type      string                 `json:"type"`
+	Time      time.Time              `json:"time"`
+	Browser   string                 `json:"browser,omitempty"`
+	TargetID  proto.TargetTargetID   `json:"targetId"`
+	RequestID proto.NetworkRequestID `json:"requestId"`
+	Method    string                 `json:"method,omitempty"`
+	URL       string                 `json:"url"`
+	Status    int                    `json:"status,omitempty"`
+	MIMEType  string                 `json:"mimeType,omitempty"`
+	Headers   proto.NetworkHeaders   `json:"headers,omitempty"`
+	PostData  string                 `json:"postData,omitempty"`
+}
+
+var stdoutLock sync.Mutex
+
+// printJSONLine writes v to stdout as a single line. Concurrent callers never
+// interleave.
+func printJSONLine(v interface{}) {
+	line, err := json.Marshal(v)
+	if err != nil {
+		logrus.WithError(err).Error("json.Marshal")
+		return
+	}
+
+	stdoutLock.Lock()
+	defer stdoutLock.Unlock()
+	_, _ = os.Stdout.Write(append(line, '\n'))
+}
+
+// streamNetwork prints the network traffic of all open tabs of every
+// browser, and of the tabs opened later, as it happens.
+func streamNetwork() error {
+	return eachBrowserPage(func(p *rod.Page, browser string) {
+		go p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+			printJSONLine(networkEvent{
+				Type:      "request",
+				Time:      time.Now(),
+				Browser:   browser,
+				TargetID:  p.TargetID,
+				RequestID: e.RequestID,
+				Method:    e.Request.Method,
+				URL:       e.Request.URL,
+				Headers:   e.Request.Headers,
+				PostData:  e.Request.PostData,
+			})
+		}, func(e *proto.NetworkResponseReceived) {
+			printJSONLine(networkEvent{
+				Type:      "response",
+				Time:      time.Now(),
+				Browser:   browser,
+				TargetID:  p.TargetID,
+				RequestID: e.RequestID,
+				URL:       e.Response.URL,
+				Status:    e.Response.Status,
+				MIMEType:  e.Response.MIMEType,
+				Headers:   e.Response.Headers,
+			})
+		})()
+	})
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+)
+
+var outputDir = flag.String("output-dir", "", "write the files of the commands given a relative path into `dir`, e.g. screenshot, pdf, har")
+
+// outputPath is where a command writes the file name, under -output-dir
+// unless name is absolute.
+func outputPath(name string) string {
+	if *outputDir == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(*outputDir, name)
+}
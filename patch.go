@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+// patchCmd rewrites the bodies of the responses of a page matching a url
+// pattern until the tool is interrupted, e.g. to flip a check in a
+// challenge's js. Each -replace is paired with the -new at the same
+// position. Only the requests of that page are hijacked, the other tabs get
+// the server's responses.
+//
+//	ctfhelper patch -url-pattern glob [-regex] -replace old -new new [-replace old -new new]... <target>
+func patchCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("patch")
+	pattern := fs.String("url-pattern", "", "`glob` of the urls to patch, as in the devtools Fetch domain, e.g. *app.js*")
+	useRegex := fs.Bool("regex", false, "the -replace values are regexps, -new may use $1")
+	var olds, news stringsFlag
+	fs.Var(&olds, "replace", "text to replace, repeatable")
+	fs.Var(&news, "new", "replacement of the -replace at the same position, repeatable")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || *pattern == "" || len(olds) == 0 || len(olds) != len(news) {
+		return usageError(fs)
+	}
+	if !canHijack() {
+		return errors.New("patching needs request hijacking, which " + *browserName + " doesn't support")
+	}
+
+	var res []*regexp.Regexp
+	if *useRegex {
+		for _, old := range olds {
+			re, err := regexp.Compile(old)
+			if err != nil {
+				return err
+			}
+			res = append(res, re)
+		}
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	r := p.HijackRequests()
+	err = r.Add(*pattern, "", func(h *rod.Hijack) {
+		u := h.Request.URL().String()
+		if err := loadResponse(h); err != nil {
+			logrus.WithField("url", u).WithError(err).Error("patch: LoadResponse")
+			h.Response.Fail(proto.NetworkErrorReasonFailed)
+			return
+		}
+
+		body := h.Response.Body()
+		for i := range olds {
+			if res != nil {
+				body = res[i].ReplaceAllString(body, news[i])
+			} else {
+				body = strings.ReplaceAll(body, olds[i], news[i])
+			}
+		}
+		if body == h.Response.Body() {
+			logrus.WithField("url", u).Warn("patch: nothing replaced")
+		} else {
+			logrus.WithField("url", u).Info("patched")
+		}
+		h.Response.SetBody(body)
+		dropHeader(h.Response.Payload(), "Content-Length")
+	})
+	if err != nil {
+		return err
+	}
+	onShutdown(func() {
+		if err := r.Stop(); err != nil {
+			logrus.WithError(err).Debug("router.Stop")
+		}
+	})
+	logrus.WithFields(logrus.Fields{"target": p.TargetID, "pattern": *pattern}).Info("patching until interrupted")
+	r.Run()
+	return nil
+}
+
+// dropHeader removes the response header name, whose value no longer fits
+// the patched body.
+func dropHeader(payload *proto.FetchFulfillRequest, name string) {
+	kept := payload.ResponseHeaders[:0]
+	for _, h := range payload.ResponseHeaders {
+		if !strings.EqualFold(h.Name, name) {
+			kept = append(kept, h)
+		}
+	}
+	payload.ResponseHeaders = kept
+}
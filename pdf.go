@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// pdfCmd prints a page to a pdf file.
+//
+//	ctfhelper pdf [-landscape] [-print-background] [-scale 1] <target> <out.pdf>
+func pdfCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("pdf")
+	landscape := fs.Bool("landscape", false, "landscape orientation")
+	background := fs.Bool("print-background", false, "include the background graphics")
+	scale := fs.Float64("scale", 1, "scale of the rendering, between 0.1 and 2")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	info, err := p.Info()
+	if err != nil {
+		return err
+	}
+	if info.Type != proto.TargetTargetInfoTypePage {
+		return fmt.Errorf("%s is a %s target, only pages can be printed", p.TargetID, info.Type)
+	}
+
+	pdf, err := p.PDF(&proto.PagePrintToPDF{
+		Landscape:       *landscape,
+		PrintBackground: *background,
+		Scale:           *scale,
+	})
+	if err != nil {
+		return err
+	}
+	return utils.OutputFile(outputPath(fs.Arg(1)), pdf)
+}
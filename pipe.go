@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+)
+
+var pipeMode = flag.Bool("pipe", false, "read json commands from stdin, one per line, and answer each with a json line on stdout")
+
+// pipeRequest is a line of -pipe input, e.g.
+//
+//	{"id": 1, "cmd": "navigate", "target": "admin", "url": "https://..."}
+//
+// cmd is one of the apiCalls, id is copied into the answer as it is.
+type pipeRequest struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Cmd    string          `json:"cmd"`
+	Target string          `json:"target"`
+	URL    string          `json:"url"`
+	Expr   string          `json:"expr"`
+}
+
+// pipeResponse is a line of -pipe output.
+type pipeResponse struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	OK     bool            `json:"ok"`
+	Result interface{}     `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// runPipe answers the commands read from stdin over the one browser
+// connection until stdin ends, so fuzzing loops don't pay a connect per
+// request. The log() messages and everything else printed meanwhile go to
+// stderr, stdout is left to the answers.
+func runPipe(b *rod.Browser) error {
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for in.Scan() {
+		if len(in.Bytes()) == 0 {
+			continue
+		}
+		printJSONLine(pipeCall(b, in.Bytes()))
+	}
+	return in.Err()
+}
+
+func pipeCall(b *rod.Browser, line []byte) pipeResponse {
+	var req pipeRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return pipeResponse{Error: err.Error()}
+	}
+	res := pipeResponse{ID: req.ID}
+
+	call, ok := apiCalls[req.Cmd]
+	if !ok {
+		res.Error = fmt.Sprintf("unknown cmd %q", req.Cmd)
+		return res
+	}
+	result, err := call(b, apiRequest{TargetID: req.Target, URL: req.URL, Expr: req.Expr})
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.OK, res.Result = true, result
+	return res
+}
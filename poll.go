@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// selectorTextJS returns the text of the first element matching the
+// selector, null when there's none.
+const selectorTextJS = `s => {
+	const el = document.querySelector(s)
+	return el ? el.innerText : null
+}`
+
+// pollCmd reloads a page until the text of an element contains a flag, for
+// flags that only show up once some server side job is done.
+//
+//	ctfhelper poll -selector css [-interval 5s] [-max 60] <target>
+func pollCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("poll")
+	selector := fs.String("selector", "", "css selector of the element the flag shows up in")
+	interval := fs.Duration("interval", 5*time.Second, "delay between two reloads")
+	max := fs.Int("max", 60, "give up after this many attempts")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || *selector == "" || *max < 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := injectHooks(p); err != nil {
+		return err
+	}
+
+	var flags []string
+	attempt := 0
+	sleeper := utils.BackoffSleeper(*interval, *interval, func(d time.Duration) time.Duration { return d })
+	err = utils.Retry(b.GetContext(), sleeper, func() (bool, error) {
+		attempt++
+		if attempt > 1 {
+			if err := p.Reload(); err != nil {
+				return true, err
+			}
+		}
+		if err := p.WaitLoad(); err != nil {
+			return true, err
+		}
+		res, err := p.Eval(selectorTextJS, *selector)
+		if err != nil {
+			return true, err
+		}
+		if !res.Value.Nil() {
+			flags = findFlags(res.Value.String())
+		}
+		logrus.WithFields(logrus.Fields{"attempt": attempt, "flags": len(flags)}).Debug("poll")
+		if len(flags) > 0 {
+			return true, nil
+		}
+		if attempt >= *max {
+			return true, fmt.Errorf("no flag in %q after %d attempts", *selector, attempt)
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range flags {
+		fmt.Println(f)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/launcher"
+	"github.com/sirupsen/logrus"
+)
+
+// burpCertURL is where burp serves its CA certificate, as der, to the
+// clients of its proxy.
+const burpCertURL = "http://burp/cert"
+
+var (
+	proxyURL       = flag.String("proxy", "", "send the traffic through this http proxy `url`, e.g. http://127.0.0.1:8080 for burp: the tool's own requests, and the browser's with -launch")
+	proxyCA        = flag.String("proxy-ca", "", "with -proxy, the proxy's CA certificate `file`, pem or der, trusted by the launched chrome and the tool; by default burp's is fetched from "+burpCertURL)
+	ignoreAllCerts = flag.Bool("ignore-cert-errors", false, "make the browser accept any certificate: a launched chrome gets --ignore-certificate-errors, the tabs of an attached one ignore them while the tool runs")
+)
+
+// proxySPKI is the base64 sha256 of the proxy CA's public key, the form
+// chrome's --ignore-certificate-errors-spki-list trusts.
+var proxySPKI string
+
+// clientTransport is the transport of the tool's own requests, made on
+// first use from the default one.
+func clientTransport() *http.Transport {
+	if t, ok := noRedirectClient.Transport.(*http.Transport); ok {
+		return t
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	noRedirectClient.Transport = t
+	return t
+}
+
+// setupProxy sends the tool's own requests through -proxy and trusts its CA,
+// read from -proxy-ca or asked to burp. Without a CA the https traffic of
+// the browser gets certificate errors, unless they are ignored.
+func setupProxy() error {
+	if *proxyURL == "" {
+		if *proxyCA != "" {
+			logrus.Warn("-proxy-ca is ignored without -proxy")
+		}
+		return nil
+	}
+	u, err := url.Parse(*proxyURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("-proxy %q: want a url such as http://127.0.0.1:8080", *proxyURL)
+	}
+	t := clientTransport()
+	t.Proxy = http.ProxyURL(u)
+	if !*launch {
+		logrus.Warn("-proxy only covers the tool's own requests without -launch, start chrome with --proxy-server=" + *proxyURL + " for the rest")
+	}
+
+	var der []byte
+	if *proxyCA != "" {
+		if der, err = readCert(*proxyCA); err != nil {
+			return fmt.Errorf("-proxy-ca: %w", err)
+		}
+	} else if der, err = fetchBurpCert(t); err != nil {
+		logrus.WithError(err).Debug("fetchBurpCert")
+		if !*ignoreAllCerts {
+			logrus.Warn("no CA certificate from the proxy, give it with -proxy-ca or use -ignore-cert-errors for https")
+		}
+		return nil
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("proxy CA: %w", err)
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	proxySPKI = base64.StdEncoding.EncodeToString(sum[:])
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	pool.AddCert(cert)
+	t.TLSClientConfig.RootCAs = pool
+	logrus.WithFields(logrus.Fields{"subject": cert.Subject.String(), "spki": proxySPKI}).Debug("trusting the proxy CA")
+	return nil
+}
+
+// readCert reads a certificate file, pem or der, as der.
+func readCert(file string) ([]byte, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		if block.Type != "CERTIFICATE" {
+			return nil, fmt.Errorf("%s: a pem %s, not a CERTIFICATE", file, block.Type)
+		}
+		return block.Bytes, nil
+	}
+	return data, nil
+}
+
+// fetchBurpCert asks the proxy of t for burp's CA certificate.
+func fetchBurpCert(t *http.Transport) ([]byte, error) {
+	client := &http.Client{Transport: t, Timeout: 3 * time.Second}
+	res, err := client.Get(burpCertURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = res.Body.Close() }()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(burpCertURL + ": " + res.Status)
+	}
+	return ioutil.ReadAll(res.Body)
+}
+
+// proxyLaunchFlags makes a launched chrome use -proxy, for the loopback
+// hosts as well, trusting the proxy CA for this profile only, and sets
+// -ignore-cert-errors.
+func proxyLaunchFlags(l *launcher.Launcher) {
+	if *proxyURL != "" {
+		l.Set("proxy-server", *proxyURL)
+		// chrome bypasses the proxy for localhost unless told not to
+		l.Set("proxy-bypass-list", "<-loopback>")
+		if proxySPKI != "" {
+			l.Set("ignore-certificate-errors-spki-list", proxySPKI)
+		}
+	}
+	if *ignoreAllCerts {
+		l.Set("ignore-certificate-errors")
+	}
+}
+
+// ignoreAllCertErrors makes the open tabs of an attached browser, and the
+// ones opened later, accept any certificate.
+func ignoreAllCertErrors(b *rod.Browser) error {
+	return eachPage(b, func(p *rod.Page) {
+		if err := ignoreCertErrors(p); err != nil {
+			logrus.WithField("target", p.TargetID).WithError(err).Error("ignoreCertErrors")
+		}
+	})
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	quiet    = flag.Bool("q", false, "quiet, only print the command's result and errors, and exit after it instead of waiting for log() messages")
+	logLevel = flag.String("log-level", "info", "debug, info, warn or error, of the messages on stderr")
+)
+
+// setLogLevel applies -log-level. Logs go to stderr, stdout only carries the
+// page content so it stays pipeable.
+func setLogLevel() error {
+	level, err := logrus.ParseLevel(*logLevel)
+	if err != nil {
+		return err
+	}
+	logrus.SetLevel(level)
+	return nil
+}
+
+// setQuiet silences everything but errors, which logrus still writes to stderr.
+func setQuiet(b *rod.Browser) {
+	logrus.SetLevel(logrus.ErrorLevel)
+	b.Logger(utils.LoggerQuiet)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/sirupsen/logrus"
+)
+
+var recordFile = flag.String("record", "", "append each invocation that succeeded to the session `file` read by export-test")
+
+// recordedCall is one line of a -record session file.
+type recordedCall struct {
+	Time time.Time `json:"time"`
+	Args []string  `json:"args"`
+
+	// Command is the subcommand name, empty for the positional dump and
+	// navigate forms.
+	Command   string `json:"command,omitempty"`
+	FlagRegex string `json:"flagRegex"`
+
+	// Params are the arguments the command got after its options, the
+	// target first, and Options the options given, by name.
+	Params  []string          `json:"params,omitempty"`
+	Options map[string]string `json:"options,omitempty"`
+	// TargetURL is the url the target was at when the command ran, where
+	// the replay opens it.
+	TargetURL string `json:"targetUrl,omitempty"`
+}
+
+// recording is what the command of a -record call parsed, written with the
+// call once it succeeded.
+var recording struct {
+	params    []string
+	options   map[string]string
+	targetURL string
+}
+
+// recordArgs keeps the arguments and options fs parsed for -record.
+func recordArgs(fs *flag.FlagSet) {
+	if *recordFile == "" {
+		return
+	}
+	recording.params = fs.Args()
+	recording.options = map[string]string{}
+	fs.Visit(func(f *flag.Flag) {
+		// the target -match or -index picked is the first param, a command's
+		// own -index, like click's, leaves targetIndex empty
+		if f.Name == "match" && targetMatch != "" || f.Name == "index" && targetIndex != "" {
+			return
+		}
+		recording.options[f.Name] = f.Value.String()
+	})
+}
+
+// recordTarget keeps the url of the first page the command resolved for
+// -record.
+func recordTarget(p *rod.Page) {
+	if *recordFile == "" || recording.targetURL != "" {
+		return
+	}
+	info, err := p.Info()
+	if err != nil {
+		logrus.WithField("target", p.TargetID).WithError(err).Debug("recordTarget")
+		return
+	}
+	recording.targetURL = info.URL
+}
+
+func recordCall(file string, args []string, command string) error {
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	line, err := json.Marshal(recordedCall{time.Now(), args, command, *flagRegex, recording.params, recording.options, recording.targetURL})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+func readRecordedCalls(file string) ([]recordedCall, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	calls := []recordedCall{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var c recordedCall
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, err
+		}
+		calls = append(calls, c)
+	}
+	return calls, scanner.Err()
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+const maxRedirects = 20
+
+// redirectsCmd follows the redirect chain of url hop by hop with the target's
+// cookies, printing every hop. With -redirect-bodies the body of each hop,
+// which the browser throws away for 30x responses, is printed or saved too.
+//
+//	ctfhelper redirects [-redirect-bodies] [-out dir] <target> <url>
+func redirectsCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("redirects")
+	withBodies := fs.Bool("redirect-bodies", false, "capture the body of every hop")
+	out := fs.String("out", "", "save each hop's body into `dir` as 00_302.txt, 01_200.txt, ...")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	next := fs.Arg(1)
+	for hop := 0; hop < maxRedirects; hop++ {
+		req, err := newPageRequest(p, http.MethodGet, next, nil)
+		if err != nil {
+			return err
+		}
+		res, err := noRedirectClient.Do(req)
+		if err != nil {
+			return err
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		_ = res.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		location, _ := res.Location()
+		if location != nil {
+			fmt.Printf("%02d %d %s -> %s\n", hop, res.StatusCode, next, location)
+		} else {
+			fmt.Printf("%02d %d %s\n", hop, res.StatusCode, next)
+		}
+
+		if *withBodies {
+			if *out != "" {
+				name := filepath.Join(*out, fmt.Sprintf("%02d_%d.txt", hop, res.StatusCode))
+				if err := utils.OutputFile(name, body); err != nil {
+					return err
+				}
+			} else {
+				fmt.Printf("%s\n", body)
+			}
+		}
+
+		if location == nil {
+			return nil
+		}
+		next = location.String()
+	}
+	return fmt.Errorf("stopped after %d redirects", maxRedirects)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+const reflectSnippetRadius = 40
+
+// reflectCmd replaces every query parameter of the url template with a unique
+// canary, navigates the target to it and reports where each canary shows up.
+//
+//	ctfhelper reflect <target> <url-template>
+func reflectCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("reflect")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	targetID, tpl := fs.Arg(0), fs.Arg(1)
+
+	u, err := url.Parse(tpl)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	if len(query) == 0 {
+		return fmt.Errorf("url template %q has no query parameters", tpl)
+	}
+
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+
+	canaries := map[string]string{}
+	for _, name := range params {
+		canary := "ctfh" + utils.RandString(4)
+		canaries[name] = canary
+		query.Set(name, canary)
+	}
+	u.RawQuery = query.Encode()
+
+	p, err := resolveTarget(b, targetID)
+	if err != nil {
+		return err
+	}
+	html, err := loadHTML(p, u.String())
+	if err != nil {
+		return err
+	}
+
+	for _, name := range params {
+		canary := canaries[name]
+		found := false
+		for offset := 0; ; {
+			i := strings.Index(html[offset:], canary)
+			if i < 0 {
+				break
+			}
+			i += offset
+			found = true
+			fmt.Printf("%s\t%s\t%q\n", name, reflectionContext(html[:i]), snippet(html, i, len(canary)))
+			offset = i + len(canary)
+		}
+		if !found {
+			fmt.Printf("%s\tnot reflected\n", name)
+		}
+	}
+	return nil
+}
+
+// reflectionContext guesses where in the document the text following prefix
+// lands: inside a comment, a script, a tag's attributes or the html body.
+func reflectionContext(prefix string) string {
+	lower := strings.ToLower(prefix)
+	switch {
+	case strings.LastIndex(lower, "<!--") > strings.LastIndex(lower, "-->"):
+		return "comment"
+	case strings.LastIndex(lower, "<script") > strings.LastIndex(lower, "</script"):
+		return "script"
+	case strings.LastIndex(lower, "<") > strings.LastIndex(lower, ">"):
+		return "attribute"
+	default:
+		return "body"
+	}
+}
+
+func snippet(s string, i, n int) string {
+	from := i - reflectSnippetRadius
+	if from < 0 {
+		from = 0
+	}
+	to := i + n + reflectSnippetRadius
+	if to > len(s) {
+		to = len(s)
+	}
+	return s[from:to]
+}
+
+// loadHTML navigates p to u and returns the resulting document.
+func loadHTML(p *rod.Page, u string) (string, error) {
+	if err := p.Navigate(u); err != nil {
+		return "", err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return "", err
+	}
+	res, err := p.Eval("document.documentElement.outerHTML")
+	if err != nil {
+		return "", err
+	}
+	return res.Value.String(), nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/gookit/color"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+)
+
+const replHelp = `js expressions are evaluated in the page, a line ending in \ or with
+unclosed brackets continues on the next one.
+  .dump              print the page's html
+  .screenshot [file] save a png of the viewport, screenshot-<time>.png by default
+  .cookies           print the page's cookies
+  .history           print the previous inputs, !N evaluates input N again
+  .help              print this
+  .exit              quit`
+
+// replCmd reads js expressions from stdin and prints what they evaluate to
+// in the page, objects as indented json. The inputs are kept in
+// ~/.ctfhelper_history across sessions.
+//
+//	ctfhelper repl <target>
+func replCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("repl")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	history := openReplHistory()
+
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var expr strings.Builder
+	fmt.Print("> ")
+	for in.Scan() {
+		line := in.Text()
+		if strings.HasSuffix(line, `\`) {
+			expr.WriteString(strings.TrimSuffix(line, `\`) + "\n")
+			fmt.Print(". ")
+			continue
+		}
+		expr.WriteString(line)
+		if openBrackets(expr.String()) > 0 {
+			expr.WriteString("\n")
+			fmt.Print(". ")
+			continue
+		}
+		src := strings.TrimSpace(expr.String())
+		expr.Reset()
+
+		if strings.HasPrefix(src, "!") {
+			n, err := strconv.Atoi(src[1:])
+			if err != nil || n < 0 || n >= len(history.lines) {
+				color.Red.Printf("no input %s in .history\n", src[1:])
+				fmt.Print("> ")
+				continue
+			}
+			src = history.lines[n]
+			fmt.Println(src)
+		}
+
+		cmd := ""
+		if fields := strings.Fields(src); len(fields) > 0 {
+			cmd = fields[0]
+		}
+		switch {
+		case src == "":
+		case cmd == ".exit":
+			return nil
+		case cmd == ".help":
+			fmt.Println(replHelp)
+		case cmd == ".history":
+			for i, l := range history.lines {
+				fmt.Printf("%4d  %s\n", i, strings.ReplaceAll(l, "\n", "\n      "))
+			}
+		case strings.HasPrefix(cmd, "."):
+			if err := replDotCommand(p, src); err != nil {
+				color.Red.Println(err)
+			}
+		default:
+			history.add(src)
+			res, err := p.Eval(src)
+			if err != nil {
+				color.Red.Println(err)
+			} else {
+				fmt.Println(replValue(res))
+			}
+		}
+		fmt.Print("> ")
+	}
+	return in.Err()
+}
+
+func replDotCommand(p *rod.Page, src string) error {
+	fields := strings.Fields(src)
+	switch fields[0] {
+	case ".dump":
+		html, err := ctfhelper.HTML(p)
+		if err != nil {
+			return err
+		}
+		fmt.Println(html)
+		reportFlags("repl", html)
+	case ".screenshot":
+		file := fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405"))
+		if len(fields) > 1 {
+			file = fields[1]
+		}
+		png, err := p.Screenshot(false, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+		if err != nil {
+			return err
+		}
+		file = outputPath(file)
+		if err := utils.OutputFile(file, png); err != nil {
+			return err
+		}
+		fmt.Println(file)
+	case ".cookies":
+		cookies, err := p.Cookies(nil)
+		if err != nil {
+			return err
+		}
+		for _, c := range cookies {
+			fmt.Printf("%s=%s\tdomain=%s path=%s httpOnly=%t secure=%t\n", c.Name, c.Value, c.Domain, c.Path, c.HTTPOnly, c.Secure)
+		}
+	default:
+		return fmt.Errorf("unknown %s, see .help", fields[0])
+	}
+	return nil
+}
+
+// replValue is how the repl prints a result: strings as they are, dom nodes
+// and functions by their description, other values as indented json.
+func replValue(res *proto.RuntimeRemoteObject) string {
+	switch {
+	case res.Type == proto.RuntimeRemoteObjectTypeString:
+		return res.Value.Str()
+	case res.Type == proto.RuntimeRemoteObjectTypeUndefined:
+		return "undefined"
+	case res.Subtype == proto.RuntimeRemoteObjectSubtypeNode,
+		res.Type == proto.RuntimeRemoteObjectTypeFunction,
+		res.UnserializableValue != "":
+		if res.Description != "" {
+			return res.Description
+		}
+		return string(res.UnserializableValue)
+	}
+	return res.Value.JSON("", "  ")
+}
+
+// openBrackets counts the brackets src leaves open, outside of strings and
+// comments, to tell an unfinished input.
+func openBrackets(src string) int {
+	depth := 0
+	var quote rune
+	escaped, lineComment, blockComment := false, false, false
+	rs := []rune(src)
+	for i, r := range rs {
+		next := rune(0)
+		if i+1 < len(rs) {
+			next = rs[i+1]
+		}
+		switch {
+		case lineComment:
+			lineComment = r != '\n'
+		case blockComment:
+			blockComment = !(r == '/' && i > 0 && rs[i-1] == '*')
+		case quote != 0:
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == quote:
+				quote = 0
+			}
+		case r == '/' && next == '/':
+			lineComment = true
+		case r == '/' && next == '*':
+			blockComment = true
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			depth--
+		}
+	}
+	return depth
+}
+
+// replHistory is the inputs of the repl, appended to a file as they are
+// entered. Each input is a quoted go string on its own line, so multi-line
+// inputs come back as they were typed.
+type replHistory struct {
+	lines []string
+	f     *os.File
+}
+
+func openReplHistory() *replHistory {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return &replHistory{}
+	}
+	return openHistoryFile(filepath.Join(home, ".ctfhelper_history"))
+}
+
+func openHistoryFile(file string) *replHistory {
+	h := &replHistory{}
+	if data, err := ioutil.ReadFile(file); err == nil {
+		for _, l := range strings.Split(string(data), "\n") {
+			if l == "" {
+				continue
+			}
+			// the lines of older files aren't quoted, they are kept as they are
+			if src, err := strconv.Unquote(l); err == nil {
+				l = src
+			}
+			h.lines = append(h.lines, l)
+		}
+	}
+	h.f, _ = os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	return h
+}
+
+func (h *replHistory) add(src string) {
+	h.lines = append(h.lines, src)
+	if h.f != nil {
+		_, _ = h.f.WriteString(strconv.Quote(src) + "\n")
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReplHistoryRoundTrip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "history")
+	inputs := []string{
+		"1 + 1",
+		"let a = 1 // the first\nlet b = 2\na + b",
+		"`line one\n  line two`",
+		`"quoted \"string\"" + '\t'`,
+		"x\r\ny",
+	}
+	h := openHistoryFile(file)
+	for _, src := range inputs {
+		h.add(src)
+	}
+	_ = h.f.Close()
+
+	got := openHistoryFile(file)
+	_ = got.f.Close()
+	if !reflect.DeepEqual(got.lines, inputs) {
+		t.Errorf("got %q, want %q", got.lines, inputs)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// replayStep is one request of a replay-session file. Any {{name}} in URL,
+// Headers or Body is replaced with the value an -extract rule captured from
+// an earlier response.
+type replayStep struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+
+	// Expect is the expected status code, any status below 400 is accepted
+	// if it's zero.
+	Expect int `json:"expect,omitempty"`
+}
+
+type extractRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// replaySessionCmd re-issues the requests of a session file in order through
+// the page's fetch and stops at the first unexpected status.
+//
+//	ctfhelper replay-session [-target id] [-extract name=regexp]... <file>
+func replaySessionCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("replay-session")
+	targetID := fs.String("target", "", "target to send the requests from (default the first page)")
+	extracts := stringsFlag{}
+	fs.Var(&extracts, "extract", "`name=regexp` capturing {{name}} from each response body (first group or whole match), repeatable")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	rules := []extractRule{}
+	for _, e := range extracts {
+		kv := strings.SplitN(e, "=", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("bad -extract %q, want name=regexp", e)
+		}
+		re, err := regexp.Compile(kv[1])
+		if err != nil {
+			return err
+		}
+		rules = append(rules, extractRule{kv[0], re})
+	}
+
+	data, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var steps []replayStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return err
+	}
+
+	var p *rod.Page
+	if *targetID != "" {
+		p, err = resolveTarget(b, *targetID)
+	} else {
+		var pages rod.Pages
+		pages, err = b.Pages()
+		if err == nil && pages.Empty() {
+			err = fmt.Errorf("no open pages")
+		}
+		if err == nil {
+			p = pages.First()
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	vars := map[string]string{}
+	for i, step := range steps {
+		expand := func(s string) string {
+			for name, value := range vars {
+				s = strings.Replace(s, "{{"+name+"}}", value, -1)
+			}
+			return s
+		}
+
+		method := step.Method
+		if method == "" {
+			method = "GET"
+		}
+		init := map[string]interface{}{"method": method}
+		if len(step.Headers) > 0 {
+			headers := map[string]string{}
+			for k, v := range step.Headers {
+				headers[k] = expand(v)
+			}
+			init["headers"] = headers
+		}
+		if step.Body != "" {
+			init["body"] = expand(step.Body)
+		}
+
+		u := expand(step.URL)
+		r, err := pageFetch(p, u, init)
+		if err != nil {
+			return fmt.Errorf("step %d %s %s: %w", i, method, u, err)
+		}
+		fmt.Printf("%d\t%d\t%d\t%s %s\n", i, r.Status, r.Size, method, u)
+
+		if (step.Expect != 0 && r.Status != step.Expect) || (step.Expect == 0 && r.Status >= 400) {
+			return fmt.Errorf("step %d %s %s: unexpected status %d", i, method, u, r.Status)
+		}
+
+		for _, rule := range rules {
+			m := rule.re.FindStringSubmatch(r.Body)
+			switch {
+			case len(m) > 1:
+				vars[rule.name] = m[1]
+			case len(m) == 1:
+				vars[rule.name] = m[0]
+			default:
+				continue
+			}
+			fmt.Printf("\t%s=%s\n", rule.name, vars[rule.name])
+		}
+	}
+	return nil
+}
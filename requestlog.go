@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/gookit/color"
+)
+
+var (
+	logRequests   = flag.Bool("log-requests", false, "print method, url, status and Content-Length of every request the pages make")
+	requestFilter = flag.String("filter", "", "with -log-requests, only print the urls matching this regexp")
+)
+
+// loggedRequest is what logRequestsOf remembers of a request until it finished.
+type loggedRequest struct {
+	method string
+	url    string
+	status int
+	length string
+}
+
+// startRequestLog prints a line per finished request of all open tabs of
+// every browser, and of the tabs opened later. A hijack only sees the request stage, so the
+// status and length come from the Network events instead, and the
+// challengehelperlog hijack is unaffected.
+func startRequestLog() error {
+	var filter *regexp.Regexp
+	if *requestFilter != "" {
+		var err error
+		filter, err = regexp.Compile(*requestFilter)
+		if err != nil {
+			return err
+		}
+	}
+
+	return eachBrowserPage(func(p *rod.Page, browser string) { go logRequestsOf(p, browser, filter) })
+}
+
+func logRequestsOf(p *rod.Page, browser string, filter *regexp.Regexp) {
+	// the callbacks of one EachEvent run one at a time, no lock needed
+	pending := map[proto.NetworkRequestID]*loggedRequest{}
+	p.EachEvent(func(e *proto.NetworkRequestWillBeSent) {
+		if filter != nil && !filter.MatchString(e.Request.URL) {
+			return
+		}
+		pending[e.RequestID] = &loggedRequest{method: e.Request.Method, url: e.Request.URL}
+		reportFlags("request", e.Request.URL+"\n"+e.Request.PostData)
+	}, func(e *proto.NetworkResponseReceived) {
+		if r, ok := pending[e.RequestID]; ok {
+			r.status, r.length = e.Response.Status, contentLength(e.Response.Headers)
+		}
+	}, func(e *proto.NetworkLoadingFinished) {
+		if r, ok := pending[e.RequestID]; ok {
+			delete(pending, e.RequestID)
+			printLine(withBrowser(browser, fmt.Sprintf("%-7s %d %8s %s", r.method, r.status, r.length, r.url)))
+		}
+	}, func(e *proto.NetworkLoadingFailed) {
+		if r, ok := pending[e.RequestID]; ok {
+			delete(pending, e.RequestID)
+			printLine(withBrowser(browser, fmt.Sprintf("%-7s %s %s", r.method, e.ErrorText, r.url)))
+		}
+	})()
+}
+
+// contentLength is the Content-Length header of a response, - without one,
+// e.g. for a chunked body.
+func contentLength(headers proto.NetworkHeaders) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, "Content-Length") {
+			return value.String()
+		}
+	}
+	return "-"
+}
+
+// textOut is where printLine and printColored write, stderr with -pipe so
+// stdout only carries its json answers.
+var textOut io.Writer = os.Stdout
+
+// printLine writes a line to stdout without interleaving with printJSONLine.
+func printLine(line string) {
+	stdoutLock.Lock()
+	defer stdoutLock.Unlock()
+	_, _ = fmt.Fprintln(textOut, line)
+}
+
+// printColored is printLine for a colored line, format has its newline.
+func printColored(c color.Color, format string, a ...interface{}) {
+	stdoutLock.Lock()
+	defer stdoutLock.Unlock()
+	_, _ = fmt.Fprint(textOut, c.Sprintf(format, a...))
+}
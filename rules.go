@@ -0,0 +1,377 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/sirupsen/logrus"
+)
+
+var rulesFile = flag.String("rules", "", "register the hijack rules of this json or yaml `file` at startup and reload them when it changes, "+
+	`an array of {"pattern": glob, "action": "block|log|replace-body|mock|set-header|rewrite-body|delay", "body": "..", "file": "..", `+
+	`"status": 200, "headers": {"Name": "value"}, "find": regexp, "replace": "..", "delay": "1s"}`)
+
+// hijackRule is an entry of the -rules file.
+type hijackRule struct {
+	// Pattern is a glob of the urls, as in the devtools Fetch domain.
+	Pattern string `json:"pattern"`
+
+	// Action is block, log, replace-body, mock, set-header, rewrite-body or
+	// delay.
+	Action string `json:"action"`
+
+	// Body is the response of replace-body.
+	Body string `json:"body"`
+
+	// File holds the response of mock, relative to the rules file.
+	File string `json:"file"`
+
+	// Status is the response status of replace-body and mock, 200 if 0.
+	Status int `json:"status"`
+
+	// Headers are the response headers of replace-body and mock, and the
+	// ones set-header adds or overrides.
+	Headers map[string]string `json:"headers"`
+
+	// Find is a regexp rewrite-body replaces with Replace, which may refer
+	// to its groups as $1.
+	Find    string `json:"find"`
+	Replace string `json:"replace"`
+
+	// Delay holds the request back for a while before the action, a
+	// duration such as 500ms. The delay action only does that.
+	Delay string `json:"delay"`
+
+	match *regexp.Regexp
+	find  *regexp.Regexp
+	delay time.Duration
+}
+
+// loadRules reads and checks a -rules file, json unless its extension is
+// .yaml or .yml.
+func loadRules(file string) ([]hijackRule, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules []hijackRule
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		if rules, err = parseRulesYAML(file, data); err != nil {
+			return nil, err
+		}
+	default:
+		if err := json.Unmarshal(data, &rules); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	for i := range rules {
+		if err := rules[i].check(filepath.Dir(file)); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", file, i, err)
+		}
+	}
+	return rules, nil
+}
+
+// check validates the rule and prepares it, reading the file of mock from
+// dir.
+func (rule *hijackRule) check(dir string) error {
+	if rule.Pattern == "" {
+		return fmt.Errorf("no pattern")
+	}
+	if rule.Delay != "" {
+		d, err := time.ParseDuration(rule.Delay)
+		if err != nil {
+			return fmt.Errorf("bad delay: %w", err)
+		}
+		rule.delay = d
+	}
+	switch rule.Action {
+	case "block", "log", "replace-body", "set-header":
+	case "mock":
+		if rule.File == "" {
+			return fmt.Errorf("mock needs a file")
+		}
+		file := rule.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		rule.Body = string(body)
+		if _, ok := rule.Headers["Content-Type"]; !ok {
+			if typ := mime.TypeByExtension(filepath.Ext(file)); typ != "" {
+				if rule.Headers == nil {
+					rule.Headers = map[string]string{}
+				}
+				rule.Headers["Content-Type"] = typ
+			}
+		}
+	case "rewrite-body":
+		re, err := regexp.Compile(rule.Find)
+		if err != nil || rule.Find == "" {
+			return fmt.Errorf("rewrite-body needs a find regexp: %v", err)
+		}
+		rule.find = re
+	case "delay":
+		if rule.delay == 0 {
+			return fmt.Errorf("delay needs a delay")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", rule.Action)
+	}
+	return nil
+}
+
+// parseRulesYAML reads rules written as a yaml list of maps, each rule's
+// first key after its "- " and the others aligned with it, with a nested
+// headers map, # comments and quoted values.
+func parseRulesYAML(file string, data []byte) ([]hijackRule, error) {
+	var rules []hijackRule
+	keyIndent, inHeaders := 0, false
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		indent := len(sc.Text()) - len(strings.TrimLeft(sc.Text(), " "))
+
+		if line == "-" || strings.HasPrefix(line, "- ") {
+			rules = append(rules, hijackRule{})
+			rest := strings.TrimPrefix(line, "-")
+			keyIndent, inHeaders = indent+1+len(rest)-len(strings.TrimLeft(rest, " ")), false
+			if line = strings.TrimSpace(rest); line == "" {
+				// the first key, on the next line, sets the indent
+				keyIndent = -1
+				continue
+			}
+			indent = keyIndent
+		}
+		if len(rules) == 0 {
+			return nil, fmt.Errorf("%s:%d: want a list of rules, each starting with -", file, n)
+		}
+		rule := &rules[len(rules)-1]
+
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("%s:%d: want key: value", file, n)
+		}
+		key := strings.TrimSpace(kv[0])
+		if key == "headers" && !noValue(kv[1]) {
+			return nil, fmt.Errorf("%s:%d: headers wants Name: value lines below it", file, n)
+		}
+		v, err := configValue(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		if inHeaders && indent > keyIndent {
+			rule.Headers[key] = v
+			continue
+		}
+		inHeaders = false
+		if keyIndent == -1 {
+			keyIndent = indent
+		}
+		if indent != keyIndent {
+			return nil, fmt.Errorf("%s:%d: %s is indented unlike the other keys of its rule", file, n, key)
+		}
+		if err := rule.set(key, v); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, n, err)
+		}
+		if key == "headers" {
+			inHeaders = true
+		}
+	}
+	return rules, sc.Err()
+}
+
+// set sets the field of a yaml key.
+func (rule *hijackRule) set(key, v string) error {
+	fields := map[string]*string{
+		"pattern": &rule.Pattern,
+		"action":  &rule.Action,
+		"body":    &rule.Body,
+		"file":    &rule.File,
+		"find":    &rule.Find,
+		"replace": &rule.Replace,
+		"delay":   &rule.Delay,
+	}
+	switch {
+	case fields[key] != nil:
+		*fields[key] = v
+	case key == "status":
+		status, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("bad status %q", v)
+		}
+		rule.Status = status
+	case key == "headers" && v == "":
+		rule.Headers = map[string]string{}
+	default:
+		return fmt.Errorf("unknown key %s", key)
+	}
+	return nil
+}
+
+// startupRules are the hijack rules of -rules, then the ones of -block and
+// -mock.
+func startupRules() ([]hijackRule, error) {
+	var rules []hijackRule
+	if *rulesFile != "" {
+		var err error
+		if rules, err = loadRules(*rulesFile); err != nil {
+			return nil, err
+		}
+	}
+	more, err := blockRules()
+	if err != nil {
+		return nil, err
+	}
+	return append(rules, more...), nil
+}
+
+// ruleSet holds the hijack rules, read by the handler of every router and
+// swapped whole when the -rules file is reloaded.
+type ruleSet struct {
+	lock  sync.RWMutex
+	rules []hijackRule
+}
+
+func newRuleSet(rules []hijackRule) *ruleSet {
+	s := &ruleSet{}
+	s.set(rules)
+	return s
+}
+
+// set replaces the rules.
+func (s *ruleSet) set(rules []hijackRule) {
+	for i := range rules {
+		rules[i].match = regexp.MustCompile(proto.PatternToReg(rules[i].Pattern))
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rules = rules
+}
+
+func (s *ruleSet) get() []hijackRule {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return s.rules
+}
+
+// addRules registers the one handler of the rules on r, which stays in place
+// when they are reloaded. The first rule matching a url, in the file's
+// order, handles it, the requests no rule matches go to the next handlers.
+func addRules(r *rod.HijackRouter, rules *ruleSet) error {
+	return r.Add("*", "", func(h *rod.Hijack) {
+		u := h.Request.URL().String()
+		for _, rule := range rules.get() {
+			if rule.match.MatchString(u) {
+				rule.handle(h)
+				return
+			}
+		}
+		h.Skip = true
+	})
+}
+
+// watchRules reloads the -rules file when it changes and swaps the rules for
+// the new ones. A file that doesn't load keeps the old rules.
+func watchRules(rules *ruleSet) {
+	modTime := func() time.Time {
+		info, err := os.Stat(*rulesFile)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+	for range time.Tick(time.Second) {
+		t := modTime()
+		if t.IsZero() || t.Equal(last) {
+			continue
+		}
+		last = t
+		next, err := startupRules()
+		if err != nil {
+			logrus.WithError(err).Error("rules: keeping the previous rules")
+			continue
+		}
+		rules.set(next)
+		logrus.WithField("count", len(next)).Info("rules reloaded")
+	}
+}
+
+func (rule hijackRule) handle(h *rod.Hijack) {
+	u := h.Request.URL().String()
+	log := logrus.WithFields(logrus.Fields{"url": u, "pattern": rule.Pattern, "action": rule.Action})
+	if rule.delay > 0 {
+		time.Sleep(rule.delay)
+	}
+
+	switch rule.Action {
+	case "block":
+		log.Debug("rule")
+		h.Response.Fail(proto.NetworkErrorReasonBlockedByClient)
+
+	case "log":
+		log.WithField("method", h.Request.Method()).Info("rule")
+		h.ContinueRequest(&proto.FetchContinueRequest{})
+
+	case "delay":
+		log.WithField("delay", rule.delay).Debug("rule")
+		h.ContinueRequest(&proto.FetchContinueRequest{})
+
+	case "replace-body", "mock":
+		log.Debug("rule")
+		if rule.Status != 0 {
+			h.Response.Payload().ResponseCode = rule.Status
+		}
+		rule.setHeaders(h.Response)
+		h.Response.SetBody(rule.Body)
+
+	case "set-header", "rewrite-body":
+		log.Debug("rule")
+		if err := loadResponse(h); err != nil {
+			log.WithError(err).Error("rule: LoadResponse")
+			h.Response.Fail(proto.NetworkErrorReasonFailed)
+			return
+		}
+		if rule.find != nil {
+			h.Response.SetBody(rule.find.ReplaceAllString(h.Response.Body(), rule.Replace))
+			dropHeader(h.Response.Payload(), "Content-Length")
+		}
+		rule.setHeaders(h.Response)
+	}
+}
+
+// setHeaders replaces the response headers named in the rule.
+func (rule hijackRule) setHeaders(res *rod.HijackResponse) {
+	names := make([]string, 0, len(rule.Headers))
+	for name := range rule.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dropHeader(res.Payload(), name)
+		res.SetHeader(name, rule.Headers[name])
+	}
+}
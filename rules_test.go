@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRulesYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []hijackRule
+		err  bool
+	}{
+		{
+			name: "rules",
+			in: "# rules\n- pattern: \"*app.js*\"\n  action: rewrite-body\n  find: 'debug = false'\n  replace: debug = true # comment\n" +
+				"\n- pattern: https://x/api/*\n  action: replace-body\n  status: 201\n  body: \"\"\n  headers:\n    Content-Type: application/json\n    X-A: \"a: b\"\n  delay: 1s\n",
+			want: []hijackRule{
+				{Pattern: "*app.js*", Action: "rewrite-body", Find: "debug = false", Replace: "debug = true"},
+				{Pattern: "https://x/api/*", Action: "replace-body", Status: 201, Body: "",
+					Headers: map[string]string{"Content-Type": "application/json", "X-A": "a: b"}, Delay: "1s"},
+			},
+		},
+		{
+			name: "first key on its own line",
+			in:   "-\n  pattern: \"*\"\n  action: block\n",
+			want: []hijackRule{{Pattern: "*", Action: "block"}},
+		},
+		{
+			name: "indented list",
+			in:   "  -   pattern: \"*.png\"\n      action: block\n",
+			want: []hijackRule{{Pattern: "*.png", Action: "block"}},
+		},
+		{name: "not a list", in: "pattern: \"*\"\n", err: true},
+		{name: "misaligned key", in: "- pattern: \"*\"\n    action: block\n", err: true},
+		{name: "unknown key", in: "- pattern: \"*\"\n  colour: red\n", err: true},
+		{name: "bad status", in: "- pattern: \"*\"\n  status: ok\n", err: true},
+		{name: "headers with a value", in: "- pattern: \"*\"\n  headers: x\n", err: true},
+		{name: "not key: value", in: "- pattern\n", err: true},
+	}
+	for _, tt := range tests {
+		got, err := parseRulesYAML("rules.yaml", []byte(tt.in))
+		if (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.err)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRuleCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		rule hijackRule
+		err  bool
+	}{
+		{name: "block", rule: hijackRule{Pattern: "*", Action: "block"}},
+		{name: "rewrite-body", rule: hijackRule{Pattern: "*", Action: "rewrite-body", Find: "a(b)", Replace: "$1"}},
+		{name: "delay", rule: hijackRule{Pattern: "*", Action: "delay", Delay: "10ms"}},
+		{name: "no pattern", rule: hijackRule{Action: "block"}, err: true},
+		{name: "unknown action", rule: hijackRule{Pattern: "*", Action: "drop"}, err: true},
+		{name: "rewrite-body without find", rule: hijackRule{Pattern: "*", Action: "rewrite-body"}, err: true},
+		{name: "bad find", rule: hijackRule{Pattern: "*", Action: "rewrite-body", Find: "("}, err: true},
+		{name: "delay without delay", rule: hijackRule{Pattern: "*", Action: "delay"}, err: true},
+		{name: "bad delay", rule: hijackRule{Pattern: "*", Action: "log", Delay: "soon"}, err: true},
+		{name: "mock without file", rule: hijackRule{Pattern: "*", Action: "mock"}, err: true},
+		{name: "mock of a missing file", rule: hijackRule{Pattern: "*", Action: "mock", File: "missing.js"}, err: true},
+	}
+	for _, tt := range tests {
+		if err := tt.rule.check(t.TempDir()); (err != nil) != tt.err {
+			t.Errorf("%s: error = %v, want error %t", tt.name, err, tt.err)
+		}
+	}
+}
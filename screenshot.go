@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// screenshotCmd saves a png of what the page currently shows, or of the
+// element -selector matches, clipped to its bounding box. The vendored
+// protocol has no CaptureBeyondViewport, so -full lets rod grow the viewport
+// to the content size for the capture. Without a file, or with -, the png
+// goes to stdout, e.g. for an image viewer.
+//
+//	ctfhelper screenshot [-full] [-selector css] [-o out.png] <target> [out.png]
+func screenshotCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("screenshot")
+	fullPage := fs.Bool("full", false, "capture the whole scrollable page instead of the viewport")
+	fs.BoolVar(fullPage, "full-page", false, "same as -full")
+	selector := fs.String("selector", "", "capture only the first element matching this `css` selector")
+	out := fs.String("o", "", "write the png to `file`, - for stdout (default stdout)")
+	parseArgs(fs, args)
+	if fs.NArg() < 1 || fs.NArg() > 2 || (*out != "" && fs.NArg() == 2) {
+		return usageError(fs)
+	}
+	if fs.NArg() == 2 {
+		*out = fs.Arg(1)
+	}
+	if *fullPage && *selector != "" {
+		return fmt.Errorf("-full and -selector can't be used together")
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var png []byte
+	if *selector != "" {
+		el, err := firstElement(p, *selector)
+		if err != nil {
+			return err
+		}
+		png, err = el.Screenshot(proto.PageCaptureScreenshotFormatPng, 0)
+		if err != nil {
+			return err
+		}
+	} else {
+		png, err = p.Screenshot(*fullPage, &proto.PageCaptureScreenshot{
+			Format: proto.PageCaptureScreenshotFormatPng,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if *out == "" || *out == "-" {
+		_, err = os.Stdout.Write(png)
+		return err
+	}
+	return utils.OutputFile(outputPath(*out), png)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// scrollBottomJS scrolls to the end of the document and returns its height.
+const scrollBottomJS = `() => {
+	window.scrollTo(0, document.body.scrollHeight)
+	return document.body.scrollHeight
+}`
+
+// scrollCmd scrolls a page so that lazy loaded content shows up: by -by
+// pixels, or with -to-bottom to the end of the document until it stops
+// growing, e.g. on an infinite feed.
+//
+//	ctfhelper scroll [-to-bottom | -by N] [-settle 500ms] [-max-scrolls 50] [-dump] <target>
+func scrollCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("scroll")
+	toBottom := fs.Bool("to-bottom", false, "scroll to the bottom until the page height stops growing")
+	by := fs.Int("by", 0, "scroll down by this many pixels, up when negative")
+	settle := fs.Duration("settle", 500*time.Millisecond, "how long to let content load after each scroll")
+	maxScrolls := fs.Int("max-scrolls", 50, "with -to-bottom, give up growing the page after this many scrolls")
+	dump := fs.Bool("dump", false, "print the html once done")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 || *toBottom == (*by != 0) || *maxScrolls < 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *by != 0 {
+		if _, err := p.Eval(`n => window.scrollBy(0, n)`, *by); err != nil {
+			return err
+		}
+		time.Sleep(*settle)
+	} else {
+		last := -1
+		for i := 1; ; i++ {
+			res, err := p.Eval(scrollBottomJS)
+			if err != nil {
+				return err
+			}
+			time.Sleep(*settle)
+			height := res.Value.Int()
+			if height == last {
+				logrus.WithFields(logrus.Fields{"scrolls": i, "height": height}).Debug("page stopped growing")
+				break
+			}
+			if i == *maxScrolls {
+				logrus.WithField("height", height).Warnf("page still growing after %d scrolls", i)
+				break
+			}
+			last = height
+		}
+	}
+
+	if !*dump {
+		return nil
+	}
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n", html)
+	reportFlags("scroll", html)
+	return nil
+}
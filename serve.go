@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+// apiRequest is the json body of the POST endpoints of serve, or the query
+// parameters of the GET ones. TargetID is resolved like the <target>
+// argument of the commands.
+type apiRequest struct {
+	TargetID string `json:"targetID"`
+	Expr     string `json:"expr,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// pngImage is a screenshot, serve sends it as it is, json as base64.
+type pngImage []byte
+
+// serveCmd exposes the browser to other tools as an http api, keeping the
+// connection and the log() hijack up between requests:
+//
+//	GET  /pages, /list                 [{index, targetID, url, title}] of the pages
+//	GET  /dump?target=..               {"html": ...} of the page
+//	GET  /screenshot?target=..         a png of the page's viewport
+//	POST /eval     {targetID, expr}     {"result": ...} of the js expression
+//	POST /navigate {targetID, url}      {"html": ...} once loaded, with log() hooked
+//
+// Errors are {"error": ...}. Requests are handled one at a time.
+//
+// The pages the browser visits can reach the api too, so every request must
+// carry "Authorization: Bearer <token>", the token printed at startup unless
+// given with -token. Requests with an Origin header, sent by browsers, are
+// refused, and POST bodies must be application/json, which a cross-origin
+// form or simple request can't send.
+//
+//	ctfhelper serve [-listen 127.0.0.1:8080] [-token token]
+func serveCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("serve")
+	addr := fs.String("listen", "127.0.0.1:8080", "address to listen on")
+	fs.StringVar(addr, "addr", "127.0.0.1:8080", "same as -listen")
+	token := fs.String("token", "", "the bearer `token` clients must send, random by default")
+	parseArgs(fs, args)
+	if fs.NArg() != 0 {
+		return usageError(fs)
+	}
+	if *token == "" {
+		*token = randomToken()
+	}
+
+	var lock sync.Mutex
+	handle := func(method string, fn func(b *rod.Browser, req apiRequest) (interface{}, error)) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := checkAPIRequest(r, method, *token); err != nil {
+				http.Error(w, err.Error(), err.status)
+				return
+			}
+			var req apiRequest
+			if method == http.MethodPost {
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+			} else {
+				q := r.URL.Query()
+				req = apiRequest{TargetID: q.Get("target"), Expr: q.Get("expr"), URL: q.Get("url")}
+				if req.TargetID == "" {
+					req.TargetID = q.Get("targetID")
+				}
+			}
+
+			lock.Lock()
+			res, err := fn(b, req)
+			lock.Unlock()
+
+			if png, ok := res.(pngImage); ok && err == nil {
+				w.Header().Set("Content-Type", "image/png")
+				_, _ = w.Write(png)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				res = map[string]string{"error": err.Error()}
+			}
+			_ = json.NewEncoder(w).Encode(res)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/pages", handle(http.MethodGet, apiPages))
+	mux.Handle("/list", handle(http.MethodGet, apiPages))
+	mux.Handle("/dump", handle(http.MethodGet, apiDump))
+	mux.Handle("/screenshot", handle(http.MethodGet, apiScreenshot))
+	mux.Handle("/eval", handle(http.MethodPost, apiEval))
+	mux.Handle("/navigate", handle(http.MethodPost, apiNavigate))
+
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	onShutdown(func() { _ = srv.Shutdown(context.Background()) })
+	logrus.WithFields(logrus.Fields{"addr": *addr, "token": *token}).Info("serving, send Authorization: Bearer <token>")
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// apiError is a refused api request and its status.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+// checkAPIRequest refuses the requests of another method, without the
+// token, from a browser, or with a POST body that isn't json.
+func checkAPIRequest(r *http.Request, method, token string) *apiError {
+	if r.Method != method {
+		return &apiError{http.StatusMethodNotAllowed, "use " + method}
+	}
+	if r.Header.Get("Origin") != "" {
+		return &apiError{http.StatusForbidden, "requests from browsers are refused"}
+	}
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") ||
+		subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(auth, "Bearer ")), []byte(token)) != 1 {
+		return &apiError{http.StatusUnauthorized, "missing or wrong Authorization: Bearer <token>"}
+	}
+	if method == http.MethodPost {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			return &apiError{http.StatusUnsupportedMediaType, "send Content-Type: application/json"}
+		}
+	}
+	return nil
+}
+
+// randomToken is a token nobody can guess.
+func randomToken() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+// apiCalls are the operations of serve and -pipe, by name.
+var apiCalls = map[string]func(b *rod.Browser, req apiRequest) (interface{}, error){
+	"pages":      apiPages,
+	"eval":       apiEval,
+	"navigate":   apiNavigate,
+	"dump":       apiDump,
+	"screenshot": apiScreenshot,
+}
+
+func apiPages(b *rod.Browser, _ apiRequest) (interface{}, error) {
+	return clientOf(b).Pages()
+}
+
+func apiEval(b *rod.Browser, req apiRequest) (interface{}, error) {
+	p, err := resolveTarget(b, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.Eval(req.Expr)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"result": res.Value.Val()}, nil
+}
+
+func apiNavigate(b *rod.Browser, req apiRequest) (interface{}, error) {
+	p, err := resolveTarget(b, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	if err := injectHooks(p); err != nil {
+		return nil, err
+	}
+	if err := p.Navigate(req.URL); err != nil {
+		return nil, err
+	}
+	if err := p.WaitLoad(); err != nil {
+		return nil, err
+	}
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"html": html}, nil
+}
+
+func apiDump(b *rod.Browser, req apiRequest) (interface{}, error) {
+	p, err := resolveTarget(b, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	html, err := ctfhelper.HTML(p)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"html": html}, nil
+}
+
+func apiScreenshot(b *rod.Browser, req apiRequest) (interface{}, error) {
+	p, err := resolveTarget(b, req.TargetID)
+	if err != nil {
+		return nil, err
+	}
+	png, err := p.Screenshot(false, &proto.PageCaptureScreenshot{Format: proto.PageCaptureScreenshotFormatPng})
+	if err != nil {
+		return nil, err
+	}
+	return pngImage(png), nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	shutdownLock sync.Mutex
+	shutdownFns  []func()
+	shutdownOnce sync.Once
+	rootCancel   context.CancelFunc
+)
+
+// onShutdown registers fn to run when the tool exits or is interrupted,
+// before the browser connection is closed. They run in reverse order of
+// registration.
+func onShutdown(fn func()) {
+	shutdownLock.Lock()
+	defer shutdownLock.Unlock()
+	shutdownFns = append(shutdownFns, fn)
+}
+
+// shutdown runs the shutdown functions once, then cancels the root context,
+// which disconnects from the browser without closing it.
+func shutdown() {
+	shutdownOnce.Do(func() {
+		shutdownLock.Lock()
+		for i := len(shutdownFns) - 1; i >= 0; i-- {
+			shutdownFns[i]()
+		}
+		shutdownLock.Unlock()
+
+		if rootCancel != nil {
+			rootCancel()
+		}
+	})
+}
+
+// exit shuts down and exits with code.
+func exit(code int) {
+	shutdown()
+	os.Exit(code)
+}
+
+// handleSignals makes SIGINT and SIGTERM shut down and exit 0. A second
+// signal exits at once, for a shutdown that hangs.
+func handleSignals(cancel context.CancelFunc) {
+	rootCancel = cancel
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		s := <-sig
+		logrus.WithField("signal", s).Debug("shutting down")
+		go func() {
+			<-sig
+			logrus.Warn("interrupted again, exiting without cleaning up")
+			os.Exit(exitFailure)
+		}()
+		exit(0)
+	}()
+}
+
+// waitTimeout waits for wg, at most for d, and reports whether it finished.
+func waitTimeout(wg *sync.WaitGroup, d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
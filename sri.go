@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/gookit/color"
+)
+
+const sriJS = `() => [...document.querySelectorAll("script[src], link[href][rel~=stylesheet], link[href][rel~=preload], link[href][rel~=modulepreload], link[integrity]")].map(el => {
+	const url = new URL(el.src || el.href, document.baseURI)
+	return {tag: el.localName, url: url.href, integrity: el.integrity || "", crossOrigin: url.origin !== location.origin}
+})`
+
+const fetchBase64JS = `async (url) => {
+	const r = await fetch(url, {credentials: "include"})
+	const buf = new Uint8Array(await r.arrayBuffer())
+	let s = ""
+	for (let i = 0; i < buf.length; i += 0x8000) s += String.fromCharCode.apply(null, buf.subarray(i, i + 0x8000))
+	return btoa(s)
+}`
+
+var sriHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha384": sha512.New384,
+	"sha512": sha512.New,
+}
+
+var sriStrength = map[string]int{"sha256": 1, "sha384": 2, "sha512": 3}
+
+// sriCmd recomputes the integrity hashes of the page's scripts and styles
+// and flags mismatches and cross-origin scripts without integrity.
+//
+//	ctfhelper sri <target>
+func sriCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("sri")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval(sriJS)
+	if err != nil {
+		return err
+	}
+	var resources []struct {
+		Tag         string `json:"tag"`
+		URL         string `json:"url"`
+		Integrity   string `json:"integrity"`
+		CrossOrigin bool   `json:"crossOrigin"`
+	}
+	if err := unmarshalValue(res, &resources); err != nil {
+		return err
+	}
+
+	for _, r := range resources {
+		if r.Integrity == "" {
+			if r.Tag == "script" && r.CrossOrigin {
+				color.Yellow.Printf("MISSING\t%s\t%s\n", r.Tag, r.URL)
+			} else {
+				fmt.Printf("none\t%s\t%s\n", r.Tag, r.URL)
+			}
+			continue
+		}
+
+		data, err := resourceBytes(p, r.URL)
+		if err != nil {
+			color.Red.Printf("ERROR\t%s\t%s\t%s\n", r.Tag, r.URL, err)
+			continue
+		}
+
+		if ok, got := checkSRI(r.Integrity, data); ok {
+			color.Green.Printf("OK\t%s\t%s\n", r.Tag, r.URL)
+		} else {
+			color.Red.Printf("MISMATCH\t%s\t%s\n\tdeclared %s\n\tactual   %s\n", r.Tag, r.URL, r.Integrity, got)
+		}
+	}
+	return nil
+}
+
+// resourceBytes returns the bytes the page loaded for url, falling back to
+// fetching it again from the page.
+func resourceBytes(p *rod.Page, url string) ([]byte, error) {
+	if data, err := p.GetResource(url); err == nil {
+		return data, nil
+	}
+	res, err := p.Eval(fetchBase64JS, url)
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(res.Value.String())
+}
+
+// checkSRI reports whether data matches the integrity metadata. As in the
+// spec only the strongest algorithm listed counts. got is data's hash with
+// that algorithm.
+func checkSRI(integrity string, data []byte) (ok bool, got string) {
+	strongest := ""
+	for _, meta := range strings.Fields(integrity) {
+		alg := strings.SplitN(meta, "-", 2)[0]
+		if sriStrength[alg] > sriStrength[strongest] {
+			strongest = alg
+		}
+	}
+	if strongest == "" {
+		return false, "unsupported algorithm"
+	}
+
+	h := sriHashes[strongest]()
+	h.Write(data)
+	got = strongest + "-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	for _, meta := range strings.Fields(integrity) {
+		if strings.SplitN(meta, "?", 2)[0] == got {
+			return true, got
+		}
+	}
+	return false, got
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/gookit/color"
+)
+
+// sstiPayload is a template expression and what it renders to when the
+// template engine evaluates it.
+type sstiPayload struct {
+	engines string
+	expr    string
+	result  string
+}
+
+var sstiPayloads = []sstiPayload{
+	{"jinja2/twig/nunjucks/angular", "{{7*7}}", "49"},
+	{"jinja2 (not twig)", "{{7*'7'}}", "7777777"},
+	{"freemarker/velocity/el/js template literal", "${7*7}", "49"},
+	{"ruby/pug/thymeleaf", "#{7*7}", "49"},
+	{"erb/ejs", "<%= 7*7 %>", "49"},
+	{"smarty", "{7*7}", "49"},
+	{"thymeleaf", "[[${7*7}]]", "49"},
+	{"spring", "*{7*7}", "49"},
+	{"razor", "@(7*7)", "49"},
+	{"dot", "{{=7*7}}", "49"},
+	{"mako", "${{7*7}}", "49"},
+}
+
+// sstiCmd puts each template expression, wrapped in markers, into every
+// query parameter in turn and reports the ones that come back evaluated.
+//
+//	ctfhelper ssti <target> <url-template>
+func sstiCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("ssti")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+	targetID, tpl := fs.Arg(0), fs.Arg(1)
+
+	u, err := url.Parse(tpl)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	if len(query) == 0 {
+		return fmt.Errorf("url template %q has no query parameters", tpl)
+	}
+	params := make([]string, 0, len(query))
+	for name := range query {
+		params = append(params, name)
+	}
+	sort.Strings(params)
+
+	p, err := resolveTarget(b, targetID)
+	if err != nil {
+		return err
+	}
+
+	const marker = "ctfh"
+	for _, name := range params {
+		vulnerable := false
+		for _, payload := range sstiPayloads {
+			q := u.Query()
+			q.Set(name, marker+payload.expr+marker)
+			target := *u
+			target.RawQuery = q.Encode()
+
+			html, err := loadHTML(p, target.String())
+			if err != nil {
+				return err
+			}
+			if strings.Contains(html, marker+payload.result+marker) {
+				vulnerable = true
+				color.Red.Printf("%s\t%s\tevaluated, %s\n", name, payload.expr, payload.engines)
+			}
+		}
+		if !vulnerable {
+			fmt.Printf("%s\tnothing evaluated\n", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-rod/rod"
+)
+
+// stateScripts are css selectors of script tags that hold inline json state.
+var stateScripts = []string{
+	"script#__NEXT_DATA__",
+	"script#__NUXT_DATA__",
+	"script#__APOLLO_STATE__",
+	"script[type='application/json']",
+	"script[type='application/ld+json']",
+}
+
+// stateGlobals are window properties frameworks use for preloaded state.
+var stateGlobals = []string{
+	"__NEXT_DATA__",
+	"__NUXT__",
+	"__INITIAL_STATE__",
+	"__PRELOADED_STATE__",
+	"__REDUX_STATE__",
+	"__APOLLO_STATE__",
+	"__remixContext",
+}
+
+const stateJS = `(scripts, globals) => {
+	const found = []
+	const seen = new Set()
+	for (const sel of scripts) {
+		document.querySelectorAll(sel).forEach(el => {
+			if (seen.has(el)) return
+			seen.add(el)
+			found.push({source: sel + (el.id ? " #" + el.id : ""), text: el.textContent})
+		})
+	}
+	for (const name of globals) {
+		if (window[name] === undefined) continue
+		try {
+			found.push({source: "window." + name, text: JSON.stringify(window[name])})
+		} catch (e) {
+			found.push({source: "window." + name, text: String(e)})
+		}
+	}
+	return found
+}`
+
+// stateCmd prints the inline json state embedded by SPA frameworks.
+//
+//	ctfhelper state <target>
+func stateCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("state")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	res, err := p.Eval(stateJS, stateScripts, stateGlobals)
+	if err != nil {
+		return err
+	}
+
+	var found []struct {
+		Source string `json:"source"`
+		Text   string `json:"text"`
+	}
+	if err := unmarshalValue(res, &found); err != nil {
+		return err
+	}
+
+	for _, s := range found {
+		fmt.Printf("// %s\n", s.Source)
+		out := bytes.Buffer{}
+		if err := json.Indent(&out, []byte(s.Text), "", "  "); err != nil {
+			fmt.Printf("%s\n\n", s.Text)
+			continue
+		}
+		fmt.Printf("%s\n\n", out.String())
+	}
+	return nil
+}
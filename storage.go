@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+)
+
+// storageDumpJS reads both storages. Sandboxed or opaque origins throw on
+// access, failed then tells which origin and storage it was.
+const storageDumpJS = `() => {
+	let failed = ""
+	const read = (name) => {
+		try {
+			const s = window[name], out = {}
+			for (let i = 0; i < s.length; i++) out[s.key(i)] = s.getItem(s.key(i))
+			return out
+		} catch (e) {
+			failed = location.origin + ": " + name + ": " + e
+		}
+	}
+	const dump = {origin: location.origin, local: read("localStorage"), session: read("sessionStorage")}
+	return {dump, failed}
+}`
+
+const storageSetJS = `(name, key, value) => {
+	try {
+		window[name].setItem(key, value)
+		return ""
+	} catch (e) {
+		return location.origin + ": " + e
+	}
+}`
+
+// frameStorage is the storage of one frame, as storage dump -frames prints
+// it. Error tells why a storage, or the whole frame, couldn't be read.
+type frameStorage struct {
+	Frame   proto.PageFrameID `json:"frame"`
+	URL     string            `json:"url"`
+	Origin  string            `json:"origin"`
+	Local   map[string]string `json:"local"`
+	Session map[string]string `json:"session"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// storageCmd prints the localStorage and sessionStorage of a page as
+// {origin, local, session}, or sets one entry. With -frames dump reads them
+// in every frame of the page too, each in an isolated world of the frame so
+// the page's scripts can't interfere; out-of-process frames only report an
+// error.
+//
+//	ctfhelper storage dump [-frames] <target> [file]
+//	ctfhelper storage set [-session] <target> <key> <value>
+func storageCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("storage")
+	session := fs.Bool("session", false, "set: write to sessionStorage instead of localStorage")
+	frames := fs.Bool("frames", false, "dump: the storage of every frame's origin, as a json array")
+	addTargetFlags(fs)
+	_ = fs.Parse(args)
+	if fs.NArg() < 1 {
+		return usageError(fs)
+	}
+	// the flags may also follow the action
+	action := fs.Arg(0)
+	parseArgs(fs, fs.Args()[1:])
+	if fs.NArg() < 1 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case action == "dump" && fs.NArg() <= 2:
+		var dump string
+		if *frames {
+			dumps, err := dumpFrameStorage(p)
+			if err != nil {
+				return err
+			}
+			dump = utils.MustToJSON(dumps)
+		} else {
+			res, err := p.Eval(storageDumpJS)
+			if err != nil {
+				return err
+			}
+			if failed := res.Value.Get("failed").Str(); failed != "" {
+				return errors.New(failed)
+			}
+			dump = res.Value.Get("dump").JSON("", "  ")
+		}
+		if fs.NArg() == 2 {
+			return utils.OutputFile(outputPath(fs.Arg(1)), dump)
+		}
+		fmt.Println(dump)
+		return nil
+	case action == "set" && fs.NArg() == 3:
+		name := "localStorage"
+		if *session {
+			name = "sessionStorage"
+		}
+		res, err := p.Eval(storageSetJS, name, fs.Arg(1), fs.Arg(2))
+		if err != nil {
+			return err
+		}
+		if msg := res.Value.Str(); msg != "" {
+			return errors.New(msg)
+		}
+		return nil
+	}
+	return usageError(fs)
+}
+
+// dumpFrameStorage reads the storage of each frame of p, the main frame
+// first.
+func dumpFrameStorage(p *rod.Page) ([]frameStorage, error) {
+	tree, err := proto.PageGetFrameTree{}.Call(p)
+	if err != nil {
+		return nil, err
+	}
+	dumps := []frameStorage{}
+	var walk func(t *proto.PageFrameTree)
+	walk = func(t *proto.PageFrameTree) {
+		dumps = append(dumps, frameStorageOf(p, t.Frame))
+		for _, child := range t.ChildFrames {
+			walk(child)
+		}
+	}
+	walk(tree.FrameTree)
+	return dumps, nil
+}
+
+func frameStorageOf(p *rod.Page, f *proto.PageFrame) frameStorage {
+	dump := frameStorage{Frame: f.ID, URL: f.URL, Origin: f.SecurityOrigin}
+	world, err := proto.PageCreateIsolatedWorld{FrameID: f.ID, WorldName: "ctfhelper"}.Call(p)
+	if err != nil {
+		dump.Error = err.Error()
+		return dump
+	}
+	res, err := proto.RuntimeEvaluate{
+		Expression:    "(" + storageDumpJS + ")()",
+		ContextID:     world.ExecutionContextID,
+		ReturnByValue: true,
+	}.Call(p)
+	if err != nil {
+		dump.Error = err.Error()
+		return dump
+	}
+	if res.ExceptionDetails != nil {
+		dump.Error = res.ExceptionDetails.Text
+		return dump
+	}
+	if err := json.Unmarshal([]byte(res.Result.Value.Get("dump").JSON("", "")), &dump); err != nil {
+		dump.Error = err.Error()
+		return dump
+	}
+	dump.Error = res.Result.Value.Get("failed").Str()
+	return dump
+}
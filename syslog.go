@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	logSyslog = flag.String("log-syslog", "", "also forward every log() message to the syslog server at `addr` (udp by default, or tcp://host:port, framed by octet counting)")
+	syslogTag = flag.String("syslog-tag", "ctfhelper", "syslog app-name of forwarded messages")
+)
+
+// syslogWriter sends RFC 5424 messages to a remote syslog server.
+type syslogWriter struct {
+	lock     sync.Mutex
+	conn     net.Conn
+	tcp      bool
+	hostname string
+	tag      string
+}
+
+func dialSyslog(addr, tag string) (*syslogWriter, error) {
+	network := "udp"
+	if strings.HasPrefix(addr, "tcp://") {
+		network, addr = "tcp", strings.TrimPrefix(addr, "tcp://")
+	} else {
+		addr = strings.TrimPrefix(addr, "udp://")
+	}
+
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogWriter{conn: conn, tcp: network == "tcp", hostname: hostname, tag: tag}, nil
+}
+
+var sdEscaper = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
+
+// Send forwards msg as user.info, tagged with the target it came from.
+func (w *syslogWriter) Send(targetID, msg string) error {
+	line := fmt.Sprintf("<14>1 %s %s %s - - [ctfhelper@32473 target=\"%s\"] %s",
+		time.Now().Format(time.RFC3339), w.hostname, w.tag, sdEscaper.Replace(targetID), msg)
+	if w.tcp {
+		// RFC 6587 octet counting, a message may hold newlines
+		line = fmt.Sprintf("%d %s", len(line), line)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_, err := w.conn.Write([]byte(line))
+	return err
+}
+
+// Close closes the connection to the syslog server.
+func (w *syslogWriter) Close() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	_ = w.conn.Close()
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSyslogTCPFraming(t *testing.T) {
+	client, server := net.Pipe()
+	w := &syslogWriter{conn: client, tcp: true, hostname: "host", tag: "tag"}
+	go func() {
+		_ = w.Send("T1", "<p>one</p>\n<p>two</p>")
+		w.Close()
+	}()
+	data, err := ioutil.ReadAll(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame := strings.SplitN(string(data), " ", 2)
+	if len(frame) != 2 || frame[0] != strconv.Itoa(len(frame[1])) {
+		t.Fatalf("not one octet counted frame: %q", data)
+	}
+	if !strings.HasSuffix(frame[1], `[ctfhelper@32473 target="T1"] <p>one</p>`+"\n<p>two</p>") {
+		t.Errorf("frame = %q", frame[1])
+	}
+}
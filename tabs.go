@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/go-rod/rod/lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// tabSet is the on-disk format of tabs-export / tabs-open.
+type tabSet struct {
+	Tabs    []string               `json:"tabs"`
+	Cookies []*proto.NetworkCookie `json:"cookies,omitempty"`
+}
+
+// tabsExportCmd saves the url of every open tab, in order, into a json file.
+//
+//	ctfhelper tabs-export [-cookies] <file>
+func tabsExportCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("tabs-export")
+	withCookies := fs.Bool("cookies", false, "also save the browser cookie jar")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	pages, err := b.Pages()
+	if err != nil {
+		return err
+	}
+
+	set := tabSet{Tabs: []string{}}
+	for _, p := range pages {
+		info, err := p.Info()
+		if err != nil {
+			logrus.WithField("TargetID", p.TargetID).WithError(err).Error("tabs-export")
+			continue
+		}
+		set.Tabs = append(set.Tabs, info.URL)
+	}
+
+	if *withCookies {
+		set.Cookies, err = b.GetCookies()
+		if err != nil {
+			return err
+		}
+	}
+
+	return utils.OutputFile(outputPath(fs.Arg(0)), set)
+}
+
+// tabsOpenCmd opens every url saved by tabs-export in a new tab, restoring
+// the saved cookies first so the tabs load already logged in.
+//
+//	ctfhelper tabs-open <file>
+func tabsOpenCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("tabs-open")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	data, err := ioutil.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var set tabSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return err
+	}
+
+	if len(set.Cookies) > 0 {
+		if err := b.SetCookies(cookieParams(set.Cookies)); err != nil {
+			return err
+		}
+	}
+
+	for _, u := range set.Tabs {
+		p, err := b.Page(proto.TargetCreateTarget{URL: u})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s %s\n", p.TargetID, u)
+	}
+	return nil
+}
+
+// cookieParams converts cookies read from the browser into the form
+// accepted by SetCookies. The browser reports host-only cookies with a
+// domain without the leading dot, setting them with a Domain would make
+// them domain cookies, so they are set for a url of their host instead.
+func cookieParams(cookies []*proto.NetworkCookie) []*proto.NetworkCookieParam {
+	params := make([]*proto.NetworkCookieParam, 0, len(cookies))
+	for _, c := range cookies {
+		param := &proto.NetworkCookieParam{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HTTPOnly: c.HTTPOnly,
+			SameSite: c.SameSite,
+			Priority: c.Priority,
+		}
+		if !strings.HasPrefix(c.Domain, ".") {
+			param.Domain, param.URL = "", cookieURL(c)
+		}
+		if !c.Session {
+			param.Expires = c.Expires
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// cookieURL is a url the host-only cookie c is sent to.
+func cookieURL(c *proto.NetworkCookie) string {
+	scheme := "http"
+	if c.Secure {
+		scheme = "https"
+	}
+	path := c.Path
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return scheme + "://" + c.Domain + path
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var tapFile = flag.String("tap", "", "also append every log() message, with a timestamp and its -decode form, to `file`")
+
+// tapWriter appends the log() messages to the -tap file, one line each.
+type tapWriter struct {
+	lock sync.Mutex
+	f    *os.File
+	w    *bufio.Writer
+}
+
+func openTap(file string) (*tapWriter, error) {
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	t := &tapWriter{f: f, w: bufio.NewWriter(f)}
+	onShutdown(t.Close)
+	return t, nil
+}
+
+// Write appends msg as a quoted line after the time and host, followed by
+// its decoded form when -decode changed it. Each message is flushed so a
+// crash loses nothing.
+func (t *tapWriter) Write(host, msg string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if host == "" {
+		host = "-"
+	}
+	fmt.Fprintf(t.w, "%s %s %q\n", time.Now().Format(time.RFC3339Nano), host, msg)
+	if decoded := decodeMessage(*decodeLog, msg); decoded != msg {
+		fmt.Fprintf(t.w, "\tdecoded %q\n", decoded)
+	}
+	if err := t.w.Flush(); err != nil {
+		logrus.WithError(err).Error("tap")
+	}
+}
+
+func (t *tapWriter) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	_ = t.w.Flush()
+	if err := t.f.Close(); err != nil {
+		logrus.WithError(err).Error("tap")
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/morentharia/ctfhelper/ctfhelper"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	browserPrefix = regexp.MustCompile(`^b(\d+)/(.*)$`)
+	pageIndex     = regexp.MustCompile(`^p(\d+)$`)
+	listIndex     = regexp.MustCompile(`^(b\d+/)?p?(\d+)$`)
+)
+
+// targetMatch and targetIndex back the -match and -index options of every
+// command taking a <target>, either one standing in for that argument.
+var (
+	targetMatch string
+	targetIndex string
+)
+
+// addTargetFlags adds -match and -index to the flags of a command taking a
+// <target>, unless the command has a flag of that name itself, like click's
+// -index.
+func addTargetFlags(fs *flag.FlagSet) {
+	if !strings.Contains(commands[fs.Name()].usage, "<target>") {
+		return
+	}
+	if fs.Lookup("match") == nil {
+		fs.StringVar(&targetMatch, "match", "", "pick the target by a `regexp` matching its url or title instead of the <target> argument")
+	}
+	if fs.Lookup("index") == nil {
+		fs.StringVar(&targetIndex, "index", "", "pick the target by its `index` in the list output (3 or b1/p3) instead of the <target> argument")
+	}
+}
+
+// parseArgs parses the arguments of a command like fs.Parse, then puts the
+// target -match or -index picks first among the remaining arguments.
+func parseArgs(fs *flag.FlagSet, args []string) {
+	addTargetFlags(fs)
+	_ = fs.Parse(args)
+	query, err := targetQuery()
+	if err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		exit(exitUsage)
+	}
+	if query != "" {
+		_ = fs.Parse(append([]string{query}, fs.Args()...))
+	}
+	recordArgs(fs)
+}
+
+// targetQuery turns -match and -index into a query for resolveTarget.
+func targetQuery() (string, error) {
+	switch {
+	case targetMatch != "" && targetIndex != "":
+		return "", fmt.Errorf("-match and -index can't be used together")
+	case targetMatch != "":
+		if _, err := regexp.Compile(targetMatch); err != nil {
+			return "", fmt.Errorf("bad -match: %v", err)
+		}
+		return "~" + targetMatch, nil
+	case targetIndex != "":
+		m := listIndex.FindStringSubmatch(targetIndex)
+		if m == nil {
+			return "", fmt.Errorf("bad -index %q, want an index like 3 or b1/p3", targetIndex)
+		}
+		if m[1] == "" {
+			m[1] = "b0/"
+		}
+		return m[1] + "p" + m[2], nil
+	}
+	return "", nil
+}
+
+// resolveTarget finds the page query refers to, see ctfhelper.Client.Page.
+// With several -control-url a b<N>/ prefix picks the browser, and b<N>/p<M>
+// is the page at the index list shows.
+func resolveTarget(b *rod.Browser, query string) (*rod.Page, error) {
+	p, err := findTarget(b, query)
+	if err == nil {
+		recordTarget(p)
+	}
+	return p, err
+}
+
+func findTarget(b *rod.Browser, query string) (*rod.Page, error) {
+	logrus.WithField("query", query).Debug("resolving target")
+	m := browserPrefix.FindStringSubmatch(query)
+	if m == nil {
+		return clientOf(b).Page(query)
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil || n >= len(browsers) {
+		return nil, fmt.Errorf("%w: %s: there is no browser b%d, only %d -control-url", ctfhelper.ErrNoPage, query, n, len(browsers))
+	}
+	// keep the deadline of the command's browser
+	b = browsers[n].Context(b.GetContext())
+	query = m[2]
+	if m := pageIndex.FindStringSubmatch(query); m != nil {
+		pages, err := b.Pages()
+		if err != nil {
+			return nil, err
+		}
+		i, err := strconv.Atoi(m[1])
+		if err != nil || i >= len(pages) {
+			return nil, fmt.Errorf("%w: b%d has no page %d, only %d pages", ctfhelper.ErrNoPage, n, i, len(pages))
+		}
+		return pages[i], nil
+	}
+	return clientOf(b).Page(query)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+)
+
+// navigationTimingsJS returns the phases of the last navigation in ms, from
+// the Navigation Timing api, null before any navigation.
+const navigationTimingsJS = `() => {
+	const t = performance.getEntriesByType("navigation")[0]
+	if (!t) return null
+	const ms = v => Math.round(v * 10) / 10
+	return {
+		redirect: ms(t.redirectEnd - t.redirectStart),
+		dns: ms(t.domainLookupEnd - t.domainLookupStart),
+		connect: ms(t.connectEnd - t.connectStart),
+		tls: ms(t.secureConnectionStart > 0 ? t.connectEnd - t.secureConnectionStart : 0),
+		ttfb: ms(t.responseStart - t.requestStart),
+		download: ms(t.responseEnd - t.responseStart),
+		domContentLoaded: ms(t.domContentLoadedEventEnd - t.startTime),
+		load: t.loadEventEnd ? ms(t.loadEventEnd - t.startTime) : null,
+		transferSize: t.transferSize,
+	}
+}`
+
+// printTimings writes where the time of the page's last navigation went to
+// stderr as a json line, a slow ttfb is the server, a slow load after a fast
+// download the page's own scripts.
+func printTimings(p *rod.Page) error {
+	res, err := p.Eval(navigationTimingsJS)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, res.Value.JSON("", ""))
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/gookit/color"
+	"github.com/sirupsen/logrus"
+)
+
+var hookTraffic = flag.Bool("hook-traffic", false, "wrap fetch, XMLHttpRequest and WebSocket in the page and report their traffic through log(), pairing requests with responses")
+
+// trafficPrefix marks log() messages sent by the traffic hook.
+const trafficPrefix = "ctfh-traffic:"
+
+// trafficHookJS wraps the page's fetch, XMLHttpRequest and WebSocket. Every
+// call gets a random correlation id that is reported with the request and
+// again with its response.
+const trafficHookJS = `(() => {
+	const send = window.fetch.bind(window)
+	const report = (o) => send("/challengehelperlog?target=%s", {method: "POST", body: "` + trafficPrefix + `" + JSON.stringify(o)})
+	const newID = () => Array.from(crypto.getRandomValues(new Uint32Array(2)), n => n.toString(16).padStart(8, "0")).join("")
+	const text = (body) => typeof body === "string" ? body : undefined
+
+	window.fetch = function (input, init) {
+		const url = input instanceof Request ? input.url : String(input)
+		if (url.includes("/challengehelperlog")) return send(input, init)
+		const id = newID()
+		const method = (init && init.method) || (input instanceof Request ? input.method : "GET")
+		report({id, kind: "fetch", dir: "request", method, url, body: text(init && init.body)})
+		return send(input, init).then(r => {
+			r.clone().text().then(
+				body => report({id, kind: "fetch", dir: "response", status: r.status, body}),
+				() => report({id, kind: "fetch", dir: "response", status: r.status}))
+			return r
+		}, e => {
+			report({id, kind: "fetch", dir: "error", error: String(e)})
+			throw e
+		})
+	}
+
+	const open = XMLHttpRequest.prototype.open
+	XMLHttpRequest.prototype.open = function (method, url) {
+		this.__ctfh = {id: newID(), method, url: String(url)}
+		return open.apply(this, arguments)
+	}
+	const xhrSend = XMLHttpRequest.prototype.send
+	XMLHttpRequest.prototype.send = function (body) {
+		const c = this.__ctfh
+		if (c) {
+			report({id: c.id, kind: "xhr", dir: "request", method: c.method, url: c.url, body: text(body)})
+			this.addEventListener("loadend", () => report({id: c.id, kind: "xhr", dir: "response", status: this.status,
+				body: this.responseType === "" || this.responseType === "text" ? this.responseText : undefined}))
+		}
+		return xhrSend.apply(this, arguments)
+	}
+
+	const WS = window.WebSocket
+	window.WebSocket = function (url, protocols) {
+		const ws = protocols === undefined ? new WS(url) : new WS(url, protocols)
+		const id = newID()
+		report({id, kind: "ws", dir: "open", url: String(url)})
+		const wsSend = ws.send
+		ws.send = function (data) {
+			report({id, kind: "ws", dir: "send", body: String(data)})
+			return wsSend.apply(this, arguments)
+		}
+		ws.addEventListener("message", e => report({id, kind: "ws", dir: "receive", body: String(e.data)}))
+		ws.addEventListener("close", e => report({id, kind: "ws", dir: "close", status: e.code}))
+		return ws
+	}
+	window.WebSocket.prototype = WS.prototype
+	Object.assign(window.WebSocket, {CONNECTING: 0, OPEN: 1, CLOSING: 2, CLOSED: 3})
+})()`
+
+func trafficHook(targetID proto.TargetTargetID) string {
+	return fmt.Sprintf(trafficHookJS, targetID)
+}
+
+// trafficMsg is one report of the traffic hook.
+type trafficMsg struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Dir    string `json:"dir"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Body   string `json:"body"`
+	Error  string `json:"error"`
+
+	at time.Time
+}
+
+var (
+	trafficLock    sync.Mutex
+	trafficPending = map[string]*trafficMsg{}
+)
+
+// handleTraffic prints a traffic hook report, pairing each response with the
+// request of the same correlation id.
+func handleTraffic(raw string) {
+	m := &trafficMsg{at: time.Now()}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(raw, trafficPrefix)), m); err != nil {
+		logrus.WithError(err).Error("bad traffic report")
+		return
+	}
+
+	trafficLock.Lock()
+	defer trafficLock.Unlock()
+
+	id := m.ID
+	if len(id) > 8 {
+		id = id[:8]
+	}
+
+	switch m.Dir {
+	case "request", "open":
+		trafficPending[m.ID] = m
+		printColored(color.Yellow, "[%s] %s > %s %s %s\n", id, m.Kind, m.Method, m.URL, m.Body)
+	case "response", "error", "close":
+		req, ok := trafficPending[m.ID]
+		if !ok {
+			req = &trafficMsg{at: m.at}
+		}
+		delete(trafficPending, m.ID)
+		result := fmt.Sprint(m.Status)
+		if m.Error != "" {
+			result = m.Error
+		}
+		printColored(color.Green, "[%s] %s < %s %s %s (%s) %s\n", id, m.Kind, result, req.Method, req.URL,
+			m.at.Sub(req.at).Round(time.Millisecond), m.Body)
+	case "send", "receive":
+		url := ""
+		if req, ok := trafficPending[m.ID]; ok {
+			url = req.URL
+		}
+		arrow := ">"
+		if m.Dir == "receive" {
+			arrow = "<"
+		}
+		printColored(color.Cyan, "[%s] ws %s %s %s\n", id, arrow, url, m.Body)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+)
+
+// typeCmd sends text to the focused element of a page as key presses, for
+// pages listening to keydown rather than reading the value. \t and \n in
+// the text press Tab and Enter.
+//
+//	ctfhelper type [-selector css] <target> <text>
+func typeCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("type")
+	selector := fs.String("selector", "", "focus the first element matching this css selector first")
+	parseArgs(fs, args)
+	if fs.NArg() != 2 {
+		return usageError(fs)
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *selector != "" {
+		el, err := firstElement(p, *selector)
+		if err != nil {
+			return err
+		}
+		if err := el.Focus(); err != nil {
+			return err
+		}
+	}
+	return typeText(p.Keyboard, unescapeKeys(fs.Arg(1)))
+}
+
+// typeText presses the keys of text, the characters without a key on the
+// keyboard map, e.g. non ascii ones, are inserted as they are.
+func typeText(k *rod.Keyboard, text string) error {
+	for _, r := range text {
+		if r == '\n' {
+			r = input.Enter
+		}
+		if _, ok := input.Keys[r]; !ok {
+			if err := k.InsertText(string(r)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := k.Press(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var keyEscapes = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\n`, "\n", `\r`, "\r", `\b`, "\b")
+
+// unescapeKeys turns the \t, \n, \r, \b and \\ sequences of a command line
+// argument into the characters.
+func unescapeKeys(s string) string {
+	return keyEscapes.Replace(s)
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// uaPresets are the user agents navigate's -ua-preset picks from, with the
+// navigator.platform that goes with them.
+var uaPresets = map[string]proto.NetworkSetUserAgentOverride{
+	"mobile": {
+		UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 14_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0.1 Mobile/15E148 Safari/604.1",
+		Platform:  "iPhone",
+	},
+	"android": {
+		UserAgent: "Mozilla/5.0 (Linux; Android 10; Pixel 3) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.185 Mobile Safari/537.36",
+		Platform:  "Linux armv8l",
+	},
+	"googlebot": {
+		UserAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+		Platform:  "Linux x86_64",
+	},
+	"desktop": {
+		UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/86.0.4240.198 Safari/537.36",
+		Platform:  "Win32",
+	},
+}
+
+// userAgentOverride is the override asked for by -user-agent and
+// -ua-preset, nil for none. An explicit -user-agent wins over the preset's.
+func userAgentOverride(userAgent, preset string) (*proto.NetworkSetUserAgentOverride, error) {
+	if userAgent == "" && preset == "" {
+		return nil, nil
+	}
+	override := proto.NetworkSetUserAgentOverride{}
+	if preset != "" {
+		var ok bool
+		if override, ok = uaPresets[preset]; !ok {
+			names := []string{}
+			for name := range uaPresets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown -ua-preset %q, want one of %s", preset, strings.Join(names, ", "))
+		}
+	}
+	if userAgent != "" {
+		override.UserAgent = userAgent
+	}
+	return &override, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/gookit/color"
+)
+
+var (
+	logWS    = flag.Bool("log-ws", false, "print the websocket frames every tab sends and receives, prefixed [ws], with the socket url, direction and opcode")
+	wsFile   = flag.String("ws-file", "", "append the websocket frames of every tab to this json lines `file`")
+	wsFilter = flag.String("ws-filter", "", "with -log-ws and -ws-file, only the frames whose socket url or payload matches this `regexp`")
+)
+
+// wsRecord is one line of the -ws-file, a frame and the socket it went
+// through. The payload of a binary frame is base64.
+type wsRecord struct {
+	Time      time.Time            `json:"time"`
+	Browser   string               `json:"browser,omitempty"`
+	TargetID  proto.TargetTargetID `json:"targetId"`
+	URL       string               `json:"url"`
+	Direction string               `json:"direction"`
+	Opcode    int                  `json:"opcode"`
+	Payload   string               `json:"payload"`
+}
+
+// wsCmd prints the websocket frames of a page as they are sent and
+// received, until interrupted. Text frames are printed as they are, the
+// payload of binary frames is the base64 the browser reports.
+//
+//	ctfhelper ws [-filter regexp] <target>
+func wsCmd(b *rod.Browser, args []string) error {
+	fs := newFlagSet("ws")
+	filter := fs.String("filter", "", "only print the frames whose payload matches this `regexp`")
+	parseArgs(fs, args)
+	if fs.NArg() != 1 {
+		return usageError(fs)
+	}
+
+	var re *regexp.Regexp
+	if *filter != "" {
+		var err error
+		if re, err = regexp.Compile(*filter); err != nil {
+			return err
+		}
+	}
+
+	p, err := resolveTarget(b, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	eachFrame(p, func(url, dir string, f *proto.NetworkWebSocketFrame) {
+		if re != nil && !re.MatchString(f.PayloadData) {
+			return
+		}
+		printLine(fmt.Sprintf("%s %s %s %s", dir, url, frameKind(f), f.PayloadData))
+	}, func(url string) {
+		printColored(color.Green, "open  %s\n", url)
+	}, func(url string) {
+		printColored(color.Yellow, "close %s\n", url)
+	})()
+	return nil
+}
+
+// eachFrame subscribes to the websocket events of p and calls frame for
+// every frame sent (">") or received ("<"), with the url of its socket, and
+// opened and closed, if not nil, as sockets open and close. The url of a
+// socket opened before is unknown, it's left empty. The returned func waits
+// for the events.
+func eachFrame(p *rod.Page, frame func(url, dir string, f *proto.NetworkWebSocketFrame), opened, closed func(url string)) func() {
+	// the callbacks of one EachEvent run one at a time, no lock needed
+	urls := map[proto.NetworkRequestID]string{}
+	return p.EachEvent(func(e *proto.NetworkWebSocketCreated) {
+		urls[e.RequestID] = e.URL
+		if opened != nil {
+			opened(e.URL)
+		}
+	}, func(e *proto.NetworkWebSocketClosed) {
+		if closed != nil {
+			closed(urls[e.RequestID])
+		}
+		delete(urls, e.RequestID)
+	}, func(e *proto.NetworkWebSocketFrameSent) {
+		frame(urls[e.RequestID], ">", e.Response)
+	}, func(e *proto.NetworkWebSocketFrameReceived) {
+		frame(urls[e.RequestID], "<", e.Response)
+	})
+}
+
+// frameKind is text for a text frame, else the opcode, e.g. op2 for a
+// binary one.
+func frameKind(f *proto.NetworkWebSocketFrame) string {
+	if f.Opcode == 1 {
+		return "text"
+	}
+	return fmt.Sprintf("op%d", int(f.Opcode))
+}
+
+// wsLogger prints and records the frames of every tab for -log-ws and
+// -ws-file.
+type wsLogger struct {
+	print  bool
+	file   *jsonLinesFile
+	filter *regexp.Regexp
+}
+
+// startWSLog logs the websocket frames of all open tabs of every browser, and
+// of the tabs opened later, to the console with -log-ws and to -ws-file.
+func startWSLog() error {
+	l := &wsLogger{print: *logWS}
+	if *wsFilter != "" {
+		var err error
+		if l.filter, err = regexp.Compile(*wsFilter); err != nil {
+			return fmt.Errorf("-ws-filter: %w", err)
+		}
+	}
+	if *wsFile != "" {
+		var err error
+		if l.file, err = openJSONLines(*wsFile); err != nil {
+			return err
+		}
+	}
+
+	return eachBrowserPage(l.log)
+}
+
+// log logs the frames of p in the background, until the tool exits.
+func (l *wsLogger) log(p *rod.Page, browser string) {
+	go eachFrame(p, func(url, dir string, f *proto.NetworkWebSocketFrame) {
+		if l.filter != nil && !l.filter.MatchString(url) && !l.filter.MatchString(f.PayloadData) {
+			return
+		}
+		if l.print {
+			printLine(withBrowser(browser, fmt.Sprintf("[ws] %s %s %s %s", dir, url, frameKind(f), f.PayloadData)))
+		}
+		reportFlags("ws", f.PayloadData)
+		if l.file != nil {
+			direction := "sent"
+			if dir == "<" {
+				direction = "received"
+			}
+			l.file.Write(&wsRecord{
+				Time:      time.Now(),
+				Browser:   browser,
+				TargetID:  p.TargetID,
+				URL:       url,
+				Direction: direction,
+				Opcode:    int(f.Opcode),
+				Payload:   f.PayloadData,
+			})
+		}
+	}, nil, nil)()
+}